@@ -30,8 +30,8 @@ func (a Artifact) GetData(ctx context.Context) ([]byte, error) {
 
 	code := response.StatusCode
 	if code != 200 {
-		Error.Printf("Jenkins responded with StatusCode: %d", code)
-		return nil, errors.New("Could not get File Contents")
+		a.Jenkins.Requester.logger().Error("artifact download failed", "artifact", a.Path, "status", code)
+		return nil, newAPIError(response)
 	}
 	return []byte(data), nil
 }
@@ -45,7 +45,7 @@ func (a Artifact) Save(ctx context.Context, path string) (bool, error) {
 	}
 
 	if _, err = os.Stat(path); err == nil {
-		Warning.Println("Local Copy already exists, Overwriting...")
+		a.Jenkins.Requester.logger().Warn("local copy already exists, overwriting", "artifact", a.Path, "path", path)
 	}
 
 	err = ioutil.WriteFile(path, data, 0644)
@@ -60,7 +60,6 @@ func (a Artifact) Save(ctx context.Context, path string) (bool, error) {
 // Save Artifact to directory using Artifact filename.
 func (a Artifact) SaveToDir(ctx context.Context, dir string) (bool, error) {
 	if _, err := os.Stat(dir); err != nil {
-		Error.Printf("can't save artifact: directory %s does not exist", dir)
 		return false, fmt.Errorf("can't save artifact: directory %s does not exist", dir)
 	}
 	saved, err := a.Save(ctx, path.Join(dir, a.FileName))
@@ -0,0 +1,212 @@
+package gojenkins
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Authenticator adds credentials to the requests sent to Jenkins.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// Authenticate sends the username and password with HTTP basic authentication.
+func (a *BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// APITokenAuth authenticates with a username and one of its API tokens.
+// Jenkins does not require crumbs from API token clients, so none are fetched.
+type APITokenAuth struct {
+	Username string
+	Token    string
+}
+
+func (a *APITokenAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Token)
+	return nil
+}
+
+func (a *APITokenAuth) crumbExempt() bool {
+	return true
+}
+
+// BearerTokenAuth sends a bearer token, as expected by OAuth2 reverse proxies in front of Jenkins.
+type BearerTokenAuth struct {
+	Token string
+}
+
+func (a *BearerTokenAuth) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+// Token is a credential handed out by a TokenSource.
+// A zero Expiry means the token never expires.
+type Token struct {
+	Value  string
+	Expiry time.Time
+}
+
+// TokenSource returns a fresh token each time it is called.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc adapts a function to the TokenSource interface.
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// tokenExpiryDelta refreshes tokens slightly before they expire, to allow for clock skew and latency.
+const tokenExpiryDelta = 10 * time.Second
+
+// RefreshingTokenAuth caches the token of Source and asks for a new one when it is about to expire.
+// The token is sent as the password of Username with basic authentication,
+// or as a bearer token when Username is empty.
+type RefreshingTokenAuth struct {
+	Source   TokenSource
+	Username string
+
+	mu    sync.Mutex
+	token *Token
+}
+
+func (a *RefreshingTokenAuth) Authenticate(req *http.Request) error {
+	token, err := a.currentToken(req.Context())
+	if err != nil {
+		return err
+	}
+	if a.Username != "" {
+		req.SetBasicAuth(a.Username, token.Value)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+token.Value)
+	}
+	return nil
+}
+
+// Invalidate drops the cached token, so the next request asks Source for a new one.
+func (a *RefreshingTokenAuth) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = nil
+}
+
+func (a *RefreshingTokenAuth) currentToken(ctx context.Context) (*Token, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != nil && (a.token.Expiry.IsZero() || time.Until(a.token.Expiry) > tokenExpiryDelta) {
+		return a.token, nil
+	}
+	if a.Source == nil {
+		return nil, errors.New("gojenkins: RefreshingTokenAuth has no token source")
+	}
+	token, err := a.Source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.token = token
+	return token, nil
+}
+
+type authContextKey struct{}
+
+// ContextWithAuthenticator returns a context which makes every request issued with it
+// use auth instead of the credentials configured on the Requester.
+// It lets a single client act on behalf of several Jenkins users.
+func ContextWithAuthenticator(ctx context.Context, auth Authenticator) context.Context {
+	return context.WithValue(ctx, authContextKey{}, auth)
+}
+
+// authenticator picks the credentials for a request: the per-call override first,
+// then Requester.Auth, then the legacy Requester.BasicAuth.
+func (r *Requester) authenticator(ctx context.Context) Authenticator {
+	if auth, ok := ctx.Value(authContextKey{}).(Authenticator); ok && auth != nil {
+		return auth
+	}
+	if r.Auth != nil {
+		return r.Auth
+	}
+	if r.BasicAuth != nil {
+		return r.BasicAuth
+	}
+	return nil
+}
+
+// crumbsRequired reports whether POST requests made with ctx need a crumb.
+func (r *Requester) crumbsRequired(ctx context.Context) bool {
+	if r.DisableCrumbs {
+		return false
+	}
+	exempt, ok := r.authenticator(ctx).(interface{ crumbExempt() bool })
+	return !ok || !exempt.crumbExempt()
+}
+
+// maxSessions bounds the sessions kept by a Requester, the least recently used are dropped.
+const maxSessions = 100
+
+// sessionEntry is a session in the LRU list of a Requester.
+type sessionEntry struct {
+	key     interface{}
+	session *session
+}
+
+// sessionKey identifies the user of auth. The authenticators of this package are keyed by
+// their credentials, so creating one per call still reuses the session of that user.
+// Credentials which cannot be used as a map key are not kept.
+func sessionKey(auth Authenticator) (interface{}, bool) {
+	switch a := auth.(type) {
+	case *BasicAuth:
+		if a != nil {
+			return *a, true
+		}
+	case *APITokenAuth:
+		if a != nil {
+			return *a, true
+		}
+	case *BearerTokenAuth:
+		if a != nil {
+			return *a, true
+		}
+	}
+	if auth != nil && !reflect.TypeOf(auth).Comparable() {
+		return nil, false
+	}
+	return auth, true
+}
+
+// getSession returns the crumb and cookie session of the identity used by ctx.
+// Credentials which cannot be used as a map key get a throwaway session.
+func (r *Requester) getSession(ctx context.Context) *session {
+	key, ok := sessionKey(r.authenticator(ctx))
+	if !ok {
+		return newSession()
+	}
+
+	r.sessionMu.Lock()
+	defer r.sessionMu.Unlock()
+	if r.sessions == nil {
+		r.sessions = make(map[interface{}]*list.Element)
+		r.sessionOrder = list.New()
+	}
+	if e, ok := r.sessions[key]; ok {
+		r.sessionOrder.MoveToFront(e)
+		return e.Value.(*sessionEntry).session
+	}
+	s := newSession()
+	r.sessions[key] = r.sessionOrder.PushFront(&sessionEntry{key: key, session: s})
+	if r.sessionOrder.Len() > maxSessions {
+		oldest := r.sessionOrder.Back()
+		r.sessionOrder.Remove(oldest)
+		delete(r.sessions, oldest.Value.(*sessionEntry).key)
+	}
+	return s
+}
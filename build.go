@@ -5,7 +5,6 @@ import (
 	"context"
 	"errors"
 	"net/url"
-	"regexp"
 	"strconv"
 	"time"
 )
@@ -238,7 +237,8 @@ func (b *Build) GetConsoleOutputFromIndex(ctx context.Context, startID int64) (c
 
 	querymap := make(map[string]string)
 	querymap["start"] = strstart
-	rsp, err := b.Jenkins.Requester.Get(ctx, url, &console.Content, querymap)
+	// The log of a running build grows, a cached chunk would hide the new output.
+	rsp, err := b.Jenkins.Requester.Get(ContextWithoutCache(ctx), url, &console.Content, querymap)
 	if err != nil {
 		return console, err
 	}
@@ -387,16 +387,17 @@ func (b *Build) GetUpstreamBuild(ctx context.Context) (*Build, error) {
 }
 
 func (b *Build) GetMatrixRuns(ctx context.Context) ([]*Build, error) {
-	_, err := b.Poll(ctx, 0)
+	_, err := b.PollDepth(ctx, 0)
 	if err != nil {
 		return nil, err
 	}
-	runs := b.Raw.Runs
 	result := make([]*Build, len(b.Raw.Runs))
-	r, _ := regexp.Compile(`job/(.*?)/(.*?)/(\d+)/`)
-
-	for i, run := range runs {
-		result[i] = &Build{Jenkins: b.Jenkins, Job: b.Job, Raw: new(BuildResponse), Depth: 1, Base: "/" + r.FindString(run.URL)}
+	for i, run := range b.Raw.Runs {
+		ref, err := b.Jenkins.ParseURL(run.URL)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = &Build{Jenkins: b.Jenkins, Job: b.Job, Raw: new(BuildResponse), Depth: 1, Base: ref.Path()}
 		result[i].Poll(ctx)
 	}
 	return result, nil
@@ -461,8 +462,10 @@ func (b *Build) IsGood(ctx context.Context) bool {
 	return !b.IsRunning(ctx) && b.Raw.Result == STATUS_SUCCESS
 }
 
+// IsRunning polls the build and reports whether it is still running. The build is
+// never read from the response cache, so loops waiting for it to finish see it end.
 func (b *Build) IsRunning(ctx context.Context) bool {
-	_, err := b.Poll(ctx)
+	_, err := b.Poll(ContextWithoutCache(ctx))
 	if err != nil {
 		return false
 	}
@@ -478,25 +481,40 @@ func (b *Build) SetDescription(ctx context.Context, description string) error {
 
 // Poll for current data. Optional parameter - depth.
 // More about depth here: https://wiki.jenkins-ci.org/display/JENKINS/Remote+access+API
+//
+// Deprecated: passing a depth is deprecated, use PollDepth instead.
 func (b *Build) Poll(ctx context.Context, options ...interface{}) (int, error) {
-	depth := "-1"
-
+	depth := b.Depth
 	for _, o := range options {
 		switch v := o.(type) {
 		case string:
-			depth = v
+			if d, err := strconv.Atoi(v); err == nil {
+				depth = d
+			}
 		case int:
-			depth = strconv.Itoa(v)
+			depth = v
 		case int64:
-			depth = strconv.FormatInt(v, 10)
+			depth = int(v)
 		}
 	}
-	if depth == "-1" {
-		depth = strconv.Itoa(b.Depth)
+	return b.PollDepth(ctx, depth)
+}
+
+// PollFields fetches only the fields selected by tree. Fields left out are zero in Raw.
+func (b *Build) PollFields(ctx context.Context, tree Tree) (int, error) {
+	raw := new(BuildResponse)
+	response, err := b.Jenkins.Requester.GetJSON(ctx, b.Base, raw, tree.query())
+	if err != nil {
+		return 0, err
 	}
+	b.Raw = raw
+	return response.StatusCode, nil
+}
 
+// PollDepth fetches the build data up to the given depth.
+func (b *Build) PollDepth(ctx context.Context, depth int) (int, error) {
 	qr := map[string]string{
-		"depth": depth,
+		"depth": strconv.Itoa(depth),
 	}
 	response, err := b.Jenkins.Requester.GetJSON(ctx, b.Base, b.Raw, qr)
 	if err != nil {
@@ -0,0 +1,243 @@
+package gojenkins
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheEntries  = 1000
+	defaultCacheBodySize = 1 << 20
+)
+
+// CachedResponse is a GET response kept by a CacheBackend.
+type CachedResponse struct {
+	Header   http.Header
+	Body     []byte
+	StoredAt time.Time
+}
+
+// CacheBackend stores cached responses, keyed by URL including the query.
+// Implementations must be safe for concurrent use.
+type CacheBackend interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, response *CachedResponse)
+	Delete(key string)
+	// DeletePrefix removes every entry whose key starts with prefix.
+	DeletePrefix(prefix string)
+}
+
+// CacheConfig enables caching of GET responses.
+// Responses with an ETag or Last-Modified header are revalidated with a conditional request,
+// Jenkins answers 304 Not Modified when they are still current.
+type CacheConfig struct {
+	// Backend stores the responses, nil means an in-memory LRU cache of 1000 entries.
+	Backend CacheBackend
+	// TTL serves cached responses without asking Jenkins while they are younger than TTL.
+	// Responses without validators are only cached when TTL is set.
+	TTL time.Duration
+	// MaxBodySize is the largest body cached, zero means 1 MiB.
+	MaxBodySize int
+}
+
+// responseCache is the CacheConfig in use by a Requester.
+type responseCache struct {
+	backend     CacheBackend
+	ttl         time.Duration
+	maxBodySize int
+}
+
+// SetCache enables the response cache, or disables it when config is nil.
+// It must not be called while requests are in flight.
+func (r *Requester) SetCache(config *CacheConfig) {
+	if config == nil {
+		r.cache = nil
+		return
+	}
+	c := &responseCache{backend: config.Backend, ttl: config.TTL, maxBodySize: config.MaxBodySize}
+	if c.backend == nil {
+		c.backend = NewLRUCache(defaultCacheEntries)
+	}
+	if c.maxBodySize <= 0 {
+		c.maxBodySize = defaultCacheBodySize
+	}
+	r.cache = c
+}
+
+type noCacheContextKey struct{}
+
+// ContextWithoutCache returns a context whose requests skip the response cache.
+func ContextWithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheContextKey{}, true)
+}
+
+// cacheFor returns the cache to use for a request made with ctx. Requests made on behalf
+// of another user through ContextWithAuthenticator bypass it, they may see other data.
+func (r *Requester) cacheFor(ctx context.Context) *responseCache {
+	if r.cache == nil || ctx.Value(noCacheContextKey{}) != nil {
+		return nil
+	}
+	if auth, ok := ctx.Value(authContextKey{}).(Authenticator); ok && auth != nil {
+		return nil
+	}
+	return r.cache
+}
+
+// sendCached sends a GET request through the cache.
+func (c *responseCache) sendCached(ctx context.Context, r *Requester, ar *APIRequest, URL string) (*http.Response, error) {
+	entry, ok := c.backend.Get(URL)
+	if ok {
+		if c.ttl > 0 && time.Since(entry.StoredAt) < c.ttl {
+			return entry.response(ar, URL), nil
+		}
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			ar.SetHeader("If-None-Match", etag)
+		}
+		if modified := entry.Header.Get("Last-Modified"); modified != "" {
+			ar.SetHeader("If-Modified-Since", modified)
+		}
+	}
+
+	response, err := r.send(ctx, ar, URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if ok && response.StatusCode == http.StatusNotModified {
+		io.Copy(ioutil.Discard, response.Body)
+		response.Body.Close()
+		c.backend.Set(URL, &CachedResponse{Header: entry.Header, Body: entry.Body, StoredAt: time.Now()})
+		return entry.response(ar, URL), nil
+	}
+	if response.StatusCode != http.StatusOK || !c.cacheable(response) {
+		return response, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, int64(c.maxBodySize)+1))
+	if err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+	if len(body) > c.maxBodySize {
+		response.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), response.Body), response.Body}
+		return response, nil
+	}
+	response.Body.Close()
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	c.backend.Set(URL, &CachedResponse{Header: response.Header.Clone(), Body: body, StoredAt: time.Now()})
+	return response, nil
+}
+
+func (c *responseCache) cacheable(response *http.Response) bool {
+	if strings.Contains(response.Header.Get("Cache-Control"), "no-store") {
+		return false
+	}
+	return c.ttl > 0 || response.Header.Get("ETag") != "" || response.Header.Get("Last-Modified") != ""
+}
+
+// invalidate drops the cached responses of the resource a POST to endpoint acts on,
+// e.g. /job/app/disable invalidates everything cached below /job/app/, and the api/json
+// listings of the container showing the resource, e.g. /job/team/api/json for
+// /job/team/job/app/doDelete or /computer/api/json for /computer/agent/doDelete.
+func (c *responseCache) invalidate(base string, endpoint string) {
+	resource := strings.TrimSuffix(path.Dir(strings.TrimSuffix(endpoint, "/")), "/")
+	c.backend.DeletePrefix(base + resource + "/")
+	container := path.Dir(resource)
+	if kind := path.Base(container); kind == "job" || kind == "view" {
+		container = path.Dir(container)
+	}
+	c.backend.DeletePrefix(base + path.Join("/", container, "api/json"))
+}
+
+func (e *CachedResponse) response(ar *APIRequest, URL string) *http.Response {
+	req, _ := http.NewRequest(ar.Method, URL, nil)
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// lruCache is the in-memory CacheBackend returned by NewLRUCache.
+type lruCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key      string
+	response *CachedResponse
+}
+
+// NewLRUCache returns an in-memory CacheBackend keeping the size most recently used responses.
+func NewLRUCache(size int) CacheBackend {
+	if size < 1 {
+		size = 1
+	}
+	return &lruCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *lruCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*lruEntry).response, true
+}
+
+func (c *lruCache) Set(key string, response *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*lruEntry).response = response
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, response: response})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (c *lruCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+func (c *lruCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.order.Remove(element)
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Short names of the plugins some methods depend on.
+const (
+	// PluginPipelineRESTAPI provides the wfapi endpoints used by the PipelineRun methods.
+	PluginPipelineRESTAPI  = "pipeline-rest-api"
+	PluginFolders          = "cloudbees-folder"
+	PluginCredentials      = "credentials"
+	PluginJobConfigHistory = "jobConfigHistory"
+	PluginSSHAgents        = "ssh-slaves"
+)
+
+// ErrPluginRequired is matched by every *PluginRequiredError.
+var ErrPluginRequired = errors.New("gojenkins: plugin required")
+
+// PluginRequiredError is returned by methods which need a plugin that is not installed.
+type PluginRequiredError struct {
+	// Feature is the method or feature depending on the plugin.
+	Feature string
+	Plugin  string
+}
+
+func (e *PluginRequiredError) Error() string {
+	return fmt.Sprintf("gojenkins: %s requires the %s plugin, which is not installed or not enabled", e.Feature, e.Plugin)
+}
+
+func (e *PluginRequiredError) Is(target error) bool {
+	return target == ErrPluginRequired
+}
+
+// Capabilities describes what a Jenkins controller supports.
+type Capabilities struct {
+	Version Version
+	// UseCrumbs reports whether POST requests need a CSRF crumb. When false, the
+	// Requester stops asking for crumbs until Jenkins rejects a POST for lacking one.
+	UseCrumbs   bool
+	UseSecurity bool
+	// Plugins maps the short names of the active plugins to their versions.
+	Plugins map[string]string
+}
+
+// HasPlugin reports whether the plugin with the given short name is active.
+func (c *Capabilities) HasPlugin(name string) bool {
+	_, ok := c.Plugins[name]
+	return ok
+}
+
+// ProbeCapabilities asks Jenkins for its version, security settings and plugins.
+// The result is kept, methods which need a missing plugin then fail early with a
+// *PluginRequiredError instead of an opaque 404.
+func (j *Jenkins) ProbeCapabilities(ctx context.Context) (*Capabilities, error) {
+	var root struct {
+		UseCrumbs   bool `json:"useCrumbs"`
+		UseSecurity bool `json:"useSecurity"`
+	}
+	rsp, err := j.Requester.GetJSON(ctx, "/", &root, NewTree(Fields("useCrumbs", "useSecurity")...).query())
+	if err != nil {
+		return nil, err
+	}
+	version := rsp.Header.Get("X-Jenkins")
+	j.setVersion(version)
+	j.Requester.setServerCrumbs(root.UseCrumbs)
+
+	plugins, err := j.GetPlugins(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	caps := &Capabilities{UseCrumbs: root.UseCrumbs, UseSecurity: root.UseSecurity, Plugins: make(map[string]string)}
+	caps.Version, _ = ParseVersion(version)
+	for _, p := range plugins.Raw.Plugins {
+		if p.Active && p.Enabled {
+			caps.Plugins[p.ShortName] = p.Version
+		}
+	}
+
+	j.capsMu.Lock()
+	j.caps = caps
+	j.capsMu.Unlock()
+	return caps, nil
+}
+
+// Capabilities returns the result of the last ProbeCapabilities, nil before the first one.
+func (j *Jenkins) Capabilities() *Capabilities {
+	j.capsMu.Lock()
+	defer j.capsMu.Unlock()
+	return j.caps
+}
+
+// requirePlugin fails when ProbeCapabilities found plugin missing. Without a probe
+// the request is sent and Jenkins decides.
+func (j *Jenkins) requirePlugin(feature string, plugin string) error {
+	caps := j.Capabilities()
+	if caps == nil || caps.HasPlugin(plugin) {
+		return nil
+	}
+	return &PluginRequiredError{Feature: feature, Plugin: plugin}
+}
@@ -0,0 +1,370 @@
+package gojenkins
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrConfigChanged is returned by an optimistic Job.PatchConfig when config.xml
+// was changed by someone else since it was read.
+var ErrConfigChanged = errors.New("gojenkins: config.xml changed since it was read")
+
+type configOpKind int
+
+const (
+	opSetText configOpKind = iota
+	opSetAttr
+	opInsert
+	opRemove
+)
+
+// ConfigOp is an edit of config.xml applied by PatchConfig.
+//
+// Paths name elements from the root element down, separated by slashes, e.g.
+// "properties/jenkins.model.BuildDiscarderProperty/strategy/numToKeep". The
+// first of several elements with the same name is used unless the segment has
+// a 1-based index like "hudson.tasks.Shell[2]". The empty path is the root element.
+type ConfigOp struct {
+	kind  configOpKind
+	path  string
+	name  string
+	value string
+}
+
+// SetText replaces the text of the element at path, which must not have child elements.
+func SetText(path string, text string) ConfigOp {
+	return ConfigOp{kind: opSetText, path: path, value: text}
+}
+
+// SetAttr sets the attribute name of the element at path.
+func SetAttr(path string, name string, value string) ConfigOp {
+	return ConfigOp{kind: opSetAttr, path: path, name: name, value: value}
+}
+
+// InsertElement adds element, e.g. "<hudson.tasks.Shell><command>make</command></hudson.tasks.Shell>",
+// as the last child of the element at path. It is indented like its siblings, further
+// lines of element are written as given.
+func InsertElement(path string, element string) ConfigOp {
+	return ConfigOp{kind: opInsert, path: path, value: element}
+}
+
+// RemoveElement removes the element at path.
+func RemoveElement(path string) ConfigOp {
+	return ConfigOp{kind: opRemove, path: path}
+}
+
+func (op ConfigOp) String() string {
+	switch op.kind {
+	case opSetText:
+		return fmt.Sprintf("set text of %q", op.path)
+	case opSetAttr:
+		return fmt.Sprintf("set attribute %s of %q", op.name, op.path)
+	case opInsert:
+		return fmt.Sprintf("insert into %q", op.path)
+	}
+	return fmt.Sprintf("remove %q", op.path)
+}
+
+// PatchConfig applies ops to config in order. Only the parts of config the ops
+// touch are rewritten, everything else, including comments, whitespace and
+// elements of unknown plugins, is kept byte for byte.
+func PatchConfig(config string, ops ...ConfigOp) (string, error) {
+	for _, op := range ops {
+		patched, err := op.apply(config)
+		if err != nil {
+			return "", fmt.Errorf("gojenkins: %s: %w", op, err)
+		}
+		config = patched
+	}
+	return config, nil
+}
+
+// ConfigDiff returns the unified diff between two versions of the file name,
+// or "" if they are the same.
+func ConfigDiff(name string, before string, after string) string {
+	if before == after {
+		return ""
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(before),
+		B:        difflib.SplitLines(after),
+		FromFile: "a/" + name,
+		ToFile:   "b/" + name,
+		Context:  3,
+	})
+	return diff
+}
+
+func (op ConfigOp) apply(config string) (string, error) {
+	root, err := parseConfigTree(config)
+	if err != nil {
+		return "", err
+	}
+	node, err := root.find(op.path)
+	if err != nil {
+		return "", err
+	}
+	switch op.kind {
+	case opSetText:
+		if len(node.children) > 0 {
+			return "", fmt.Errorf("%s has child elements", node.name)
+		}
+		if node.selfClosing {
+			return splice(config, node.start, node.end, node.startTag(false)+escapeText(op.value)+"</"+node.name+">"), nil
+		}
+		return splice(config, node.contentStart, node.contentEnd, escapeText(op.value)), nil
+	case opSetAttr:
+		node.setAttr(op.name, op.value)
+		return splice(config, node.start, node.contentStart, node.startTag(node.selfClosing)), nil
+	case opInsert:
+		if _, err := parseConfigTree(op.value); err != nil {
+			return "", fmt.Errorf("invalid element: %w", err)
+		}
+		return node.insert(config, strings.TrimSpace(op.value))
+	}
+	if node.parent == nil {
+		return "", errors.New("cannot remove the root element")
+	}
+	start := node.start
+	line := strings.TrimRight(config[:start], " \t")
+	if strings.HasSuffix(line, "\n") {
+		start = len(line) - 1
+	}
+	return splice(config, start, node.end, ""), nil
+}
+
+// configNode is an element of config.xml with the offsets of its markup.
+type configNode struct {
+	name     string
+	attrs    []xml.Attr
+	parent   *configNode
+	children []*configNode
+	// start and end enclose the element, contentStart and contentEnd the markup
+	// between its tags. For a self-closing element they are all end.
+	start, contentStart, contentEnd, end int
+	selfClosing                          bool
+}
+
+// parseConfigTree reads the elements of config and where they are.
+func parseConfigTree(config string) (*configNode, error) {
+	data := []byte(config)
+	// Blank the declaration rather than remove it to keep the offsets.
+	if loc := xmlDeclaration.FindIndex(data); loc != nil {
+		data = append([]byte(nil), data...)
+		for i := loc[0]; i < loc[1]; i++ {
+			data[i] = ' '
+		}
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var root, current *configNode
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.RawToken()
+		if err == io.EOF && root != nil && current == nil {
+			return root, nil
+		}
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid config.xml: %w", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if root != nil && current == nil {
+				return nil, errors.New("invalid config.xml: more than one root element")
+			}
+			node := &configNode{
+				name:         qualifiedName(token.Name),
+				attrs:        token.Copy().Attr,
+				parent:       current,
+				start:        offset,
+				contentStart: int(decoder.InputOffset()),
+			}
+			node.selfClosing = bytes.HasSuffix(data[:node.contentStart], []byte("/>"))
+			if current == nil {
+				root = node
+			} else {
+				current.children = append(current.children, node)
+			}
+			current = node
+		case xml.EndElement:
+			if current == nil || qualifiedName(token.Name) != current.name {
+				return nil, fmt.Errorf("invalid config.xml: unexpected </%s>", qualifiedName(token.Name))
+			}
+			current.contentEnd = offset
+			current.end = int(decoder.InputOffset())
+			if current.selfClosing {
+				current.contentStart, current.contentEnd = current.end, current.end
+			}
+			current = current.parent
+		}
+	}
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// find returns the element at path below n.
+func (n *configNode) find(path string) (*configNode, error) {
+	node := n
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		name, index := segment, 1
+		if open := strings.IndexByte(segment, '['); open > 0 && strings.HasSuffix(segment, "]") {
+			i, err := strconv.Atoi(segment[open+1 : len(segment)-1])
+			if err != nil || i < 1 {
+				return nil, fmt.Errorf("invalid path segment %q", segment)
+			}
+			name, index = segment[:open], i
+		}
+		var next *configNode
+		for _, child := range node.children {
+			if child.name == name {
+				if index--; index == 0 {
+					next = child
+					break
+				}
+			}
+		}
+		if next == nil {
+			return nil, fmt.Errorf("no element %s in %s", segment, node.name)
+		}
+		node = next
+	}
+	return node, nil
+}
+
+func (n *configNode) setAttr(name string, value string) {
+	for i, attr := range n.attrs {
+		if qualifiedName(attr.Name) == name {
+			n.attrs[i].Value = value
+			return
+		}
+	}
+	n.attrs = append(n.attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+// startTag writes the start tag of n, closing it if selfClosing.
+func (n *configNode) startTag(selfClosing bool) string {
+	var tag strings.Builder
+	tag.WriteString("<" + n.name)
+	for _, attr := range n.attrs {
+		tag.WriteString(" " + qualifiedName(attr.Name) + `="` + escapeAttr(attr.Value) + `"`)
+	}
+	if selfClosing {
+		tag.WriteString("/")
+	}
+	tag.WriteString(">")
+	return tag.String()
+}
+
+// indent returns the whitespace n is indented with, "" if it does not start its line.
+func (n *configNode) indent(config string) string {
+	line := config[:n.start]
+	indent := line[len(strings.TrimRight(line, " \t")):]
+	if len(indent) < len(line) && line[len(line)-len(indent)-1] != '\n' {
+		return ""
+	}
+	return indent
+}
+
+// insert adds element after the last child of n.
+func (n *configNode) insert(config string, element string) (string, error) {
+	if len(n.children) > 0 {
+		last := n.children[len(n.children)-1]
+		return splice(config, last.end, last.end, "\n"+last.indent(config)+element), nil
+	}
+	if strings.TrimSpace(config[n.contentStart:n.contentEnd]) != "" {
+		return "", fmt.Errorf("%s has text", n.name)
+	}
+	indent := n.indent(config)
+	content := "\n" + indent + "  " + element + "\n" + indent
+	if n.selfClosing {
+		return splice(config, n.start, n.end, n.startTag(false)+content+"</"+n.name+">"), nil
+	}
+	return splice(config, n.contentStart, n.contentEnd, content), nil
+}
+
+func splice(s string, start int, end int, replacement string) string {
+	return s[:start] + replacement + s[end:]
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\n", "&#10;", "\t", "&#9;")
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
+
+// PatchConfigOptions change how Job.PatchConfig writes config.xml.
+type PatchConfigOptions struct {
+	// DryRun computes the patched config and its diff without writing it.
+	DryRun bool
+	// Optimistic reads config.xml again right before writing it and fails with
+	// ErrConfigChanged if it is not the config the ops were applied to, instead
+	// of overwriting a concurrent edit.
+	Optimistic bool
+}
+
+// ConfigPatch is the outcome of Job.PatchConfig.
+type ConfigPatch struct {
+	Before string
+	After  string
+	// Diff is the unified diff from Before to After, empty if the ops changed nothing.
+	Diff string
+	// Written is true when After was posted to Jenkins.
+	Written bool
+}
+
+// PatchConfig applies ops to the config.xml of the job, see the package function
+// PatchConfig, and writes the result back unless nothing changed or opts.DryRun is set.
+// config.xml is always read from Jenkins, never from the response cache.
+func (j *Job) PatchConfig(ctx context.Context, opts PatchConfigOptions, ops ...ConfigOp) (*ConfigPatch, error) {
+	ctx = ContextWithoutCache(ctx)
+	before, err := j.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	after, err := PatchConfig(before, ops...)
+	if err != nil {
+		return nil, err
+	}
+	patch := &ConfigPatch{Before: before, After: after, Diff: ConfigDiff(strings.TrimPrefix(j.Base, "/")+"/config.xml", before, after)}
+	if patch.Diff == "" || opts.DryRun {
+		return patch, nil
+	}
+	if opts.Optimistic {
+		current, err := j.GetConfig(ctx)
+		if err != nil {
+			return patch, err
+		}
+		if current != before {
+			return patch, ErrConfigChanged
+		}
+	}
+	if err := j.UpdateConfig(ctx, after); err != nil {
+		return patch, err
+	}
+	patch.Written = true
+	return patch, nil
+}
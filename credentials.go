@@ -14,7 +14,6 @@ type CredentialsManager struct {
 	Folder string
 }
 
-const baseFolderPrefix = "/job/%s"
 const baseCredentialsURL = "%s/credentials/store/%s/domain/%s/"
 const createCredentialsURL = baseCredentialsURL + "createCredentials"
 const deleteCredentialURL = baseCredentialsURL + "credential/%s/doDelete"
@@ -111,15 +110,25 @@ type PrivateKeyFile struct {
 func (cm CredentialsManager) fillURL(url string, params ...interface{}) string {
 	var args []interface{}
 	if cm.Folder != "" {
-		args = []interface{}{fmt.Sprintf(baseFolderPrefix, cm.Folder), "folder"}
+		args = []interface{}{JobPath(cm.Folder), "folder"}
 	} else {
 		args = []interface{}{"", "system"}
 	}
-	return fmt.Sprintf(url, append(args, params...)...)
+	for _, param := range params {
+		// Domains and ids are path segments.
+		if s, ok := param.(string); ok {
+			param = escapeSegment(s)
+		}
+		args = append(args, param)
+	}
+	return fmt.Sprintf(url, args...)
 }
 
 //List ids if credentials stored inside provided domain
 func (cm CredentialsManager) List(ctx context.Context, domain string) ([]string, error) {
+	if err := cm.J.requirePlugin("CredentialsManager", PluginCredentials); err != nil {
+		return nil, err
+	}
 
 	idsResponse := credentialIDs{}
 	ids := make([]string, 0)
@@ -138,6 +147,9 @@ func (cm CredentialsManager) List(ctx context.Context, domain string) ([]string,
 //GetSingle searches for credential in given domain with given id, if credential is found
 //it will be parsed as xml to creds parameter(creds must be pointer to struct)
 func (cm CredentialsManager) GetSingle(ctx context.Context, domain string, id string, creds interface{}) error {
+	if err := cm.J.requirePlugin("CredentialsManager", PluginCredentials); err != nil {
+		return err
+	}
 	str := ""
 	err := cm.handleResponse(cm.J.Requester.Get(ctx, cm.fillURL(configCredentialURL, domain, id), &str, map[string]string{}))
 	if err != nil {
@@ -154,6 +166,9 @@ func (cm CredentialsManager) Add(ctx context.Context, domain string, creds inter
 
 //Delete credential in given domain with given id
 func (cm CredentialsManager) Delete(ctx context.Context, domain string, id string) error {
+	if err := cm.J.requirePlugin("CredentialsManager", PluginCredentials); err != nil {
+		return err
+	}
 	return cm.handleResponse(cm.J.Requester.Post(ctx, cm.fillURL(deleteCredentialURL, domain, id), nil, cm.J.Raw, map[string]string{}))
 }
 
@@ -163,6 +178,9 @@ func (cm CredentialsManager) Update(ctx context.Context, domain string, id strin
 }
 
 func (cm CredentialsManager) postCredsXML(ctx context.Context, url string, creds interface{}) error {
+	if err := cm.J.requirePlugin("CredentialsManager", PluginCredentials); err != nil {
+		return err
+	}
 	payload, err := xml.Marshal(creds)
 	if err != nil {
 		return err
@@ -176,12 +194,10 @@ func (cm CredentialsManager) handleResponse(resp *http.Response, err error) erro
 		return err
 	}
 
-	if resp.StatusCode == 409 {
-		return fmt.Errorf("Resource already exists, conflict status returned")
-	}
-
+	// 4xx and 5xx responses, including 409 for an existing credential,
+	// already come back from the Requester as an *APIError.
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("invalid response code %d", resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -0,0 +1,97 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"sync"
+)
+
+// crumb is the CSRF token issued by /crumbIssuer, sent back in the crumbRequestField header.
+type crumb struct {
+	field string
+	value string
+}
+
+// session holds the crumb together with the cookies of the web session it was issued for.
+// Jenkins binds crumbs to the session, so both have to be reused together.
+type session struct {
+	mu      sync.Mutex
+	jar     http.CookieJar
+	crumb   *crumb
+	fetched bool
+}
+
+func newSession() *session {
+	// cookiejar.New only fails when given options with a broken public suffix list.
+	jar, _ := cookiejar.New(nil)
+	return &session{jar: jar}
+}
+
+// getCrumb returns the cached crumb, asking Jenkins for one on first use.
+// A nil crumb means CSRF protection is disabled on the server.
+func (s *session) getCrumb(ctx context.Context, r *Requester) (*crumb, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fetched {
+		return s.crumb, nil
+	}
+
+	crumbData := map[string]string{}
+	// A crumb must be fresh, the response cache would hand back the rejected one.
+	_, err := r.GetJSON(ContextWithoutCache(ctx), "/crumbIssuer", &crumbData, nil)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	// Jenkins answers 404 when CSRF protection is disabled.
+	s.crumb = nil
+	if err == nil && crumbData["crumbRequestField"] != "" {
+		s.crumb = &crumb{field: crumbData["crumbRequestField"], value: crumbData["crumb"]}
+	}
+	s.fetched = true
+	return s.crumb, nil
+}
+
+// serverCrumbs reports whether Jenkins may ask for crumbs, which is the case
+// unless ProbeCapabilities found CSRF protection disabled.
+func (r *Requester) serverCrumbs() bool {
+	r.crumbsMu.Lock()
+	defer r.crumbsMu.Unlock()
+	return !r.noServerCrumbs
+}
+
+func (r *Requester) setServerCrumbs(use bool) {
+	r.crumbsMu.Lock()
+	r.noServerCrumbs = !use
+	r.crumbsMu.Unlock()
+}
+
+// invalidateCrumb forgets the cached crumb, so the next POST fetches a new one.
+func (s *session) invalidateCrumb() *crumb {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.crumb
+	s.crumb = nil
+	s.fetched = false
+	return old
+}
+
+// addCookies and saveCookies keep the session cookies when the http.Client has no jar of its own.
+func (s *session) addCookies(client *http.Client, req *http.Request) {
+	if client.Jar != nil {
+		return
+	}
+	for _, cookie := range s.jar.Cookies(req.URL) {
+		req.AddCookie(cookie)
+	}
+}
+
+func (s *session) saveCookies(client *http.Client, req *http.Request, response *http.Response) {
+	if client.Jar != nil {
+		return
+	}
+	if cookies := response.Cookies(); len(cookies) > 0 {
+		s.jar.SetCookies(req.URL, cookies)
+	}
+}
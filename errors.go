@@ -0,0 +1,119 @@
+package gojenkins
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched by *APIError through errors.Is.
+var (
+	ErrNotFound      = errors.New("gojenkins: resource not found")
+	ErrUnauthorized  = errors.New("gojenkins: unauthorized")
+	ErrForbidden     = errors.New("gojenkins: forbidden")
+	ErrConflict      = errors.New("gojenkins: resource already exists")
+	ErrCrumbRejected = errors.New("gojenkins: no valid crumb was included in the request")
+	// ErrHTMLResponse means an HTML page came back where JSON was expected, usually a login
+	// page or the error page of a proxy in front of Jenkins.
+	ErrHTMLResponse = errors.New("gojenkins: got an HTML page instead of JSON, check the credentials and any proxy in front of Jenkins")
+)
+
+// maxErrorBodySize limits how much of a failed response body is kept on an APIError.
+const maxErrorBodySize = 4096
+
+// APIError is returned when Jenkins answers with an error status code or sets the X-Error header.
+type APIError struct {
+	Method     string
+	Endpoint   string
+	StatusCode int
+	// Message holds the X-Error header sent by Jenkins, if any.
+	Message string
+	// Body holds the beginning of the response body, truncated to maxErrorBodySize bytes.
+	Body string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("jenkins: %s %s: %d %s", e.Method, e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Is reports whether the error matches one of the sentinel errors of this package,
+// so callers can write errors.Is(err, gojenkins.ErrNotFound).
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrCrumbRejected:
+		return e.StatusCode == http.StatusForbidden &&
+			(strings.Contains(e.Message, "No valid crumb") || strings.Contains(e.Body, "No valid crumb"))
+	}
+	return false
+}
+
+// newAPIError builds an APIError from a response, consuming at most maxErrorBodySize bytes of its body.
+func newAPIError(response *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: response.StatusCode,
+		Message:    response.Header.Get("X-Error"),
+	}
+	if response.Request != nil {
+		apiErr.Method = response.Request.Method
+		apiErr.Endpoint = response.Request.URL.Path
+	}
+	if response.Body != nil {
+		body, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+		apiErr.Body = string(body)
+	}
+	return apiErr
+}
+
+// unexpectedStatus is used by resource methods that only get a status code back from Poll.
+func unexpectedStatus(method string, endpoint string, status int) *APIError {
+	return &APIError{Method: method, Endpoint: endpoint, StatusCode: status}
+}
+
+// DecodeError is returned when a response body cannot be decoded.
+type DecodeError struct {
+	Endpoint    string
+	ContentType string
+	Err         error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("jenkins: decoding response of %s (%s): %v", e.Endpoint, e.ContentType, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func newDecodeError(response *http.Response, err error) *DecodeError {
+	decodeErr := &DecodeError{ContentType: response.Header.Get("Content-Type"), Err: err}
+	if response.Request != nil {
+		decodeErr.Endpoint = response.Request.URL.Path
+	}
+	return decodeErr
+}
+
+// isHTML reports whether a body is an HTML page, judging by its content type or first bytes.
+func isHTML(contentType string, body *bufio.Reader) bool {
+	if strings.HasPrefix(contentType, "text/html") {
+		return true
+	}
+	start, _ := body.Peek(512)
+	return bytes.HasPrefix(bytes.TrimSpace(start), []byte("<"))
+}
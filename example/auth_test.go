@@ -0,0 +1,183 @@
+package example
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// authRecorder remembers the Authorization header of every request per path.
+type authRecorder struct {
+	mu      sync.Mutex
+	headers map[string][]string
+}
+
+func (a *authRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	if a.headers == nil {
+		a.headers = make(map[string][]string)
+	}
+	a.headers[r.URL.Path] = append(a.headers[r.URL.Path], r.Header.Get("Authorization"))
+	a.mu.Unlock()
+	if r.URL.Path == "/crumbIssuer/api/json" {
+		w.Write([]byte(`{"crumb":"c","crumbRequestField":"Jenkins-Crumb"}`))
+		return
+	}
+	w.Write([]byte(`{}`))
+}
+
+func (a *authRecorder) get(path string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.headers[path]
+}
+
+func TestAPITokenAuthSkipsCrumb(t *testing.T) {
+	recorder := &authRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL, &gojenkins.APITokenAuth{Username: "admin", Token: "11aa"})
+	_, err := client.GetJobObj(ctx, "job1").Enable(ctx)
+	assert.Nil(t, err)
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.SetBasicAuth("admin", "11aa")
+	assert.Equal(t, []string{req.Header.Get("Authorization")}, recorder.get("/job/job1/enable"))
+	assert.Empty(t, recorder.get("/crumbIssuer/api/json"))
+}
+
+func TestBearerTokenAuth(t *testing.T) {
+	recorder := &authRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	client.Requester.Auth = &gojenkins.BearerTokenAuth{Token: "proxy-token"}
+	_, err := client.Poll(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Bearer proxy-token"}, recorder.get("/api/json"))
+}
+
+func TestRefreshingTokenAuth(t *testing.T) {
+	recorder := &authRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	issued := 0
+	source := gojenkins.TokenSourceFunc(func(ctx context.Context) (*gojenkins.Token, error) {
+		issued++
+		// The first token is already within the refresh window.
+		expiry := time.Now().Add(time.Second)
+		if issued > 1 {
+			expiry = time.Now().Add(time.Hour)
+		}
+		return &gojenkins.Token{Value: []string{"", "first", "second"}[issued], Expiry: expiry}, nil
+	})
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	client.Requester.Auth = &gojenkins.RefreshingTokenAuth{Source: source}
+	for i := 0; i < 3; i++ {
+		_, err := client.Poll(ctx)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, []string{"Bearer first", "Bearer second", "Bearer second"}, recorder.get("/api/json"))
+	assert.Equal(t, 2, issued)
+}
+
+func TestContextWithAuthenticator(t *testing.T) {
+	recorder := &authRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL, "service", "secret")
+	alice := gojenkins.ContextWithAuthenticator(ctx, &gojenkins.BearerTokenAuth{Token: "alice"})
+	_, err := client.GetJobObj(alice, "job1").Disable(alice)
+	assert.Nil(t, err)
+	_, err = client.GetJobObj(ctx, "job1").Disable(ctx)
+	assert.Nil(t, err)
+
+	disables := recorder.get("/job/job1/disable")
+	assert.Equal(t, 2, len(disables))
+	assert.Equal(t, "Bearer alice", disables[0])
+	assert.NotEqual(t, "Bearer alice", disables[1])
+	// Crumbs are bound to the user's session, every identity gets its own.
+	assert.Equal(t, 2, len(recorder.get("/crumbIssuer/api/json")))
+
+	// A new authenticator with the same credentials reuses the session of the user.
+	alice = gojenkins.ContextWithAuthenticator(ctx, &gojenkins.BearerTokenAuth{Token: "alice"})
+	_, err = client.GetJobObj(alice, "job1").Disable(alice)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(recorder.get("/crumbIssuer/api/json")))
+
+	// Sessions are dropped once many other users came by.
+	for i := 0; i < 100; i++ {
+		user := gojenkins.ContextWithAuthenticator(ctx, &gojenkins.BearerTokenAuth{Token: fmt.Sprint("user", i)})
+		_, err = client.GetJobObj(user, "job1").Disable(user)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 102, len(recorder.get("/crumbIssuer/api/json")))
+	_, err = client.GetJobObj(alice, "job1").Disable(alice)
+	assert.Nil(t, err)
+	assert.Equal(t, 103, len(recorder.get("/crumbIssuer/api/json")))
+}
+
+func TestRedirectKeepsAuthOnSameHostOnly(t *testing.T) {
+	other := &authRecorder{}
+	otherServer := httptest.NewServer(other)
+	defer otherServer.Close()
+
+	recorder := &authRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/old/api/json":
+			http.Redirect(w, r, "/job/new/api/json", http.StatusFound)
+		case "/job/elsewhere/api/json":
+			http.Redirect(w, r, otherServer.URL+"/job/elsewhere/api/json", http.StatusFound)
+		default:
+			recorder.ServeHTTP(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(nil, server.URL)
+	client.Requester.Auth = &gojenkins.BearerTokenAuth{Token: "t"}
+	_, err := client.GetJob(ctx, "old")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Bearer t"}, recorder.get("/job/new/api/json"))
+
+	_, err = client.GetJob(ctx, "elsewhere")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{""}, other.get("/job/elsewhere/api/json"))
+
+	// A client of the caller gets the policy on a copy, after its own CheckRedirect.
+	redirects := 0
+	caller := server.Client()
+	caller.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		redirects++
+		return nil
+	}
+	client, err = gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(caller), gojenkins.WithBearerToken("u"))
+	assert.Nil(t, err)
+	_, err = client.GetJob(ctx, "old")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Bearer t", "Bearer u"}, recorder.get("/job/new/api/json"))
+	assert.Equal(t, 1, redirects)
+	_, err = client.GetJob(ctx, "elsewhere")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"", ""}, other.get("/job/elsewhere/api/json"))
+	assert.Equal(t, 2, redirects)
+	assert.False(t, client.Requester.Client == caller)
+}
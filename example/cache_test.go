@@ -0,0 +1,212 @@
+package example
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/stretchr/testify/assert"
+)
+
+// etagServer serves /job/job1 with an ETag and counts full and conditional responses.
+type etagServer struct {
+	mu          sync.Mutex
+	description string
+	full        int
+	notModified int
+}
+
+func (s *etagServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.URL.Path {
+	case "/job/job1/api/json":
+		etag := `"` + s.description + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			s.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		s.full++
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(`{"name":"job1","description":"` + s.description + `"}`))
+	case "/job/job1/disable":
+		s.description = "disabled"
+	case "/api/json":
+		s.full++
+		w.Write([]byte(`{"mode":"NORMAL"}`))
+	}
+}
+
+func (s *etagServer) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.full, s.notModified
+}
+
+func TestCacheConditionalGet(t *testing.T) {
+	backend := &etagServer{description: "v1"}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	client, err := gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(server.Client()), gojenkins.WithCache(gojenkins.CacheConfig{}))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		job, err := client.GetJob(ctx, "job1")
+		assert.Nil(t, err)
+		assert.Equal(t, "v1", job.GetDescription())
+	}
+	full, notModified := backend.counts()
+	assert.Equal(t, 1, full)
+	assert.Equal(t, 2, notModified)
+
+	// Responses without validators are not cached without a TTL.
+	_, err = client.Info(ctx)
+	assert.Nil(t, err)
+	_, err = client.Info(ctx)
+	assert.Nil(t, err)
+	full, _ = backend.counts()
+	assert.Equal(t, 3, full)
+}
+
+func TestCacheTTL(t *testing.T) {
+	backend := &etagServer{description: "v1"}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	client, err := gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(server.Client()), gojenkins.WithCache(gojenkins.CacheConfig{TTL: 50 * time.Millisecond}))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := client.Info(ctx)
+		assert.Nil(t, err)
+	}
+	full, _ := backend.counts()
+	assert.Equal(t, 1, full)
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.Info(ctx)
+	assert.Nil(t, err)
+	_, err = client.Info(gojenkins.ContextWithoutCache(ctx))
+	assert.Nil(t, err)
+	full, _ = backend.counts()
+	assert.Equal(t, 3, full)
+}
+
+func TestCacheInvalidatedByPost(t *testing.T) {
+	backend := &etagServer{description: "v1"}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	client, err := gojenkins.NewClient(server.URL,
+		gojenkins.WithHTTPClient(server.Client()),
+		gojenkins.WithoutCrumbs(),
+		gojenkins.WithCache(gojenkins.CacheConfig{TTL: time.Hour}))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	job, err := client.GetJob(ctx, "job1")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", job.GetDescription())
+
+	_, err = job.Disable(ctx)
+	assert.Nil(t, err)
+	_, err = job.Poll(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "disabled", job.GetDescription())
+}
+
+func TestCacheInvalidatesContainer(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server, gojenkins.WithCache(gojenkins.CacheConfig{TTL: time.Hour}))
+	assert.Nil(t, server.AddFolder("team"))
+	assert.Nil(t, server.AddJob("team/app", jenkinstest.FreeStyleConfig))
+	assert.Nil(t, server.AddJob("top", jenkinstest.FreeStyleConfig))
+
+	folder, err := client.GetFolder(ctx, "team")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(folder.Raw.Jobs))
+	jobs, err := client.GetAllJobNames(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(jobs))
+
+	_, err = client.DeleteJob(ctx, "team/app")
+	assert.Nil(t, err)
+	folder, err = client.GetFolder(ctx, "team")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(folder.Raw.Jobs))
+
+	_, err = client.DeleteJob(ctx, "top")
+	assert.Nil(t, err)
+	jobs, err = client.GetAllJobNames(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobs))
+}
+
+func TestCachePollsBuildUntilFinished(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := newFakeClient(t, server, gojenkins.WithCache(gojenkins.CacheConfig{TTL: time.Hour}))
+	assert.Nil(t, server.AddJob("app", jenkinstest.FreeStyleConfig))
+
+	job, err := client.GetJob(ctx, "app")
+	assert.Nil(t, err)
+	queueID, err := job.InvokeSimple(ctx, nil)
+	assert.Nil(t, err)
+	task, err := client.GetQueueItem(ctx, queueID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), task.Raw.Executable.Number)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		server.StartQueued()
+	}()
+	build, err := client.GetBuildFromQueueID(ctx, job, queueID)
+	assert.Nil(t, err)
+	number := build.GetBuildNumber()
+	assert.True(t, build.IsRunning(ctx))
+	assert.Nil(t, server.AppendLog("app", number, "step 1\n"))
+	console, err := build.GetConsoleOutputFromIndex(ctx, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "step 1\n", console.Content)
+
+	assert.Nil(t, server.AppendLog("app", number, "step 2\n"))
+	assert.Nil(t, server.FinishBuild("app", number, jenkinstest.ResultSuccess))
+	for build.IsRunning(ctx) {
+		assert.Nil(t, ctx.Err())
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, "SUCCESS", build.GetResult())
+	console, err = build.GetConsoleOutputFromIndex(ctx, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "step 1\nstep 2\n", console.Content)
+}
+
+func TestLRUCache(t *testing.T) {
+	cache := gojenkins.NewLRUCache(2)
+	cache.Set("http://jenkins/job/a/api/json", &gojenkins.CachedResponse{Body: []byte("a")})
+	cache.Set("http://jenkins/job/b/api/json", &gojenkins.CachedResponse{Body: []byte("b")})
+	_, ok := cache.Get("http://jenkins/job/a/api/json")
+	assert.True(t, ok)
+	cache.Set("http://jenkins/job/c/api/json", &gojenkins.CachedResponse{Body: []byte("c")})
+
+	_, ok = cache.Get("http://jenkins/job/b/api/json")
+	assert.False(t, ok, "b was the least recently used entry")
+	cache.DeletePrefix("http://jenkins/job/a/")
+	_, ok = cache.Get("http://jenkins/job/a/api/json")
+	assert.False(t, ok)
+	_, ok = cache.Get("http://jenkins/job/c/api/json")
+	assert.True(t, ok)
+}
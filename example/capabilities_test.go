@@ -0,0 +1,159 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		cmp  int
+	}{
+		{"2.387", "2.387.0", 0},
+		{"2.387.3", "2.387", 1},
+		{"2.99", "2.387", -1},
+		{"2.401-SNAPSHOT", "2.401", 0},
+		{"2.401.1 (private-build)", "2.401.2", -1},
+	} {
+		a, err := gojenkins.ParseVersion(tc.a)
+		assert.Nil(t, err)
+		b, err := gojenkins.ParseVersion(tc.b)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.cmp, a.Compare(b), "%s <=> %s", tc.a, tc.b)
+	}
+
+	_, err := gojenkins.ParseVersion("unknown")
+	assert.NotNil(t, err)
+}
+
+func newCapabilitiesServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Jenkins", "2.401.3")
+		switch r.URL.Path {
+		case "/api/json":
+			w.Write([]byte(`{"useCrumbs":true,"useSecurity":true}`))
+		case "/pluginManager/api/json":
+			w.Write([]byte(`{"plugins":[
+				{"shortName":"credentials","version":"1271.v54b_1c2c6388a_","active":true,"enabled":true},
+				{"shortName":"cloudbees-folder","version":"6.848","active":false,"enabled":false}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestProbeCapabilities(t *testing.T) {
+	server := newCapabilitiesServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	assert.False(t, client.VersionAtLeast("2.387"), "the version is unknown before Init")
+
+	caps, err := client.ProbeCapabilities(ctx)
+	assert.Nil(t, err)
+	assert.True(t, caps.UseCrumbs)
+	assert.True(t, caps.UseSecurity)
+	assert.Equal(t, "2.401.3", caps.Version.String())
+	assert.True(t, caps.HasPlugin(gojenkins.PluginCredentials))
+	assert.False(t, caps.HasPlugin(gojenkins.PluginFolders), "disabled plugins do not count")
+	assert.True(t, client.VersionAtLeast("2.387"))
+	assert.False(t, client.VersionAtLeast("2.426"))
+}
+
+func TestPluginRequired(t *testing.T) {
+	server := newCapabilitiesServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	job := client.GetJobObj(ctx, "app")
+
+	// Without a probe the request is sent and Jenkins answers.
+	_, err := job.GetPipelineRuns(ctx)
+	assert.True(t, errors.Is(err, gojenkins.ErrNotFound))
+
+	_, err = client.ProbeCapabilities(ctx)
+	assert.Nil(t, err)
+	_, err = job.GetPipelineRuns(ctx)
+	assert.True(t, errors.Is(err, gojenkins.ErrPluginRequired))
+	assert.EqualError(t, err, "gojenkins: GetPipelineRuns requires the pipeline-rest-api plugin, which is not installed or not enabled")
+
+	_, err = client.CreateFolder(ctx, "team")
+	var pluginErr *gojenkins.PluginRequiredError
+	assert.True(t, errors.As(err, &pluginErr))
+	assert.Equal(t, gojenkins.PluginFolders, pluginErr.Plugin)
+}
+
+func TestProbedCrumbs(t *testing.T) {
+	var mu sync.Mutex
+	useCrumbs := false
+	crumbRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("X-Jenkins", "2.426.3")
+		switch r.URL.Path {
+		case "/api/json":
+			fmt.Fprintf(w, `{"useCrumbs":%t,"useSecurity":true}`, useCrumbs)
+		case "/pluginManager/api/json":
+			w.Write([]byte(`{"plugins":[]}`))
+		case "/crumbIssuer/api/json":
+			crumbRequests++
+			if !useCrumbs {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(`{"crumb":"c","crumbRequestField":"Jenkins-Crumb"}`))
+		case "/safeRestart":
+			if useCrumbs && r.Header.Get("Jenkins-Crumb") != "c" {
+				http.Error(w, "No valid crumb was included in the request", http.StatusForbidden)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	crumbsFetched := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return crumbRequests
+	}
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+
+	// The version can be read while a probe updates it.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			client.VersionAtLeast("2.400")
+		}
+	}()
+	caps, err := client.ProbeCapabilities(ctx)
+	<-done
+	assert.Nil(t, err)
+	assert.False(t, caps.UseCrumbs)
+	assert.True(t, client.VersionAtLeast("2.426"))
+
+	// No crumb is asked for once the probe found CSRF protection disabled.
+	assert.Nil(t, client.SafeRestart(ctx))
+	assert.Equal(t, 0, crumbsFetched())
+
+	// Turning it on later costs one rejected request.
+	mu.Lock()
+	useCrumbs = true
+	mu.Unlock()
+	assert.Nil(t, client.SafeRestart(ctx))
+	assert.Nil(t, client.SafeRestart(ctx))
+	assert.Equal(t, 1, crumbsFetched())
+}
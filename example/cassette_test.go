@@ -0,0 +1,226 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/stretchr/testify/assert"
+)
+
+// replayURL is the base URL of clients replaying a cassette, it is never dialed.
+const replayURL = "http://jenkins.test"
+
+// newCassetteClient returns a client replaying testdata/NAME.json and the interval to poll builds at.
+// With JENKINS_RECORD set, the cassette is recorded again, against JENKINS_URL as JENKINS_USER with the
+// API token JENKINS_TOKEN, or against a jenkinstest server without JENKINS_URL.
+//
+// The cassettes checked in were recorded against a jenkinstest server, as their source says.
+// Replaying them checks the requests the client sends and how it decodes the responses of the
+// fake, not how a real Jenkins answers. Record them from a real controller to check that.
+func newCassetteClient(t *testing.T, name string) (*gojenkins.Jenkins, time.Duration) {
+	path := filepath.Join("testdata", name+".json")
+	if _, ok := os.LookupEnv("JENKINS_RECORD"); !ok {
+		cassette, err := jenkinstest.NewCassette(path, jenkinstest.ModeReplay, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("replaying %s, recorded against %s", path, cassette.Source)
+		client, err := gojenkins.NewClient(replayURL, gojenkins.WithHTTPClient(cassette.Client()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client, 0
+	}
+
+	cassette, err := jenkinstest.NewCassette(path, jenkinstest.ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := []gojenkins.Option{gojenkins.WithHTTPClient(cassette.Client())}
+	base, ok := os.LookupEnv("JENKINS_URL")
+	if ok {
+		opts = append(opts, gojenkins.WithAPIToken(os.Getenv("JENKINS_USER"), os.Getenv("JENKINS_TOKEN")))
+	} else {
+		server := jenkinstest.NewServer(jenkinstest.WithAutoComplete(jenkinstest.ResultSuccess))
+		t.Cleanup(server.Close)
+		base = server.URL
+		cassette.Source = "jenkinstest " + jenkinstest.DefaultVersion
+	}
+	client, err := gojenkins.NewClient(base, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if cassette.Source == "" {
+			cassette.Source = "Jenkins " + client.Version
+		}
+		if err := cassette.Save(); err != nil {
+			t.Error(err)
+		}
+	})
+	return client, time.Second
+}
+
+func TestCassetteJobFlow(t *testing.T) {
+	client, interval := newCassetteClient(t, "job_flow")
+	ctx := context.Background()
+	jobData, err := ioutil.ReadFile("job.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Init(ctx)
+	assert.Nil(t, err)
+
+	job1, err := client.CreateJob(ctx, string(jobData), "Job1_test")
+	assert.Nil(t, err)
+	assert.Equal(t, "Some Job Description", job1.GetDescription())
+	assert.Equal(t, "Job1_test", job1.GetName())
+	job2, err := client.CreateJob(ctx, string(jobData), "job2_test")
+	assert.Nil(t, err)
+	assert.Equal(t, "job2_test", job2.GetName())
+
+	queueID, err := job1.InvokeSimple(ctx, map[string]string{"params1": "param1"})
+	assert.Nil(t, err)
+	assert.NotZero(t, queueID)
+	task, err := client.GetQueueItem(ctx, queueID)
+	assert.Nil(t, err)
+	assert.Equal(t, queueID, task.Raw.ID)
+
+	build, err := client.GetBuildFromQueueID(ctx, job1, queueID)
+	assert.Nil(t, err)
+	for build.IsRunning(ctx) {
+		time.Sleep(interval)
+	}
+	assert.Equal(t, "SUCCESS", build.GetResult())
+	params := build.GetParameters()
+	assert.Equal(t, "params1", params[0].Name)
+	assert.Equal(t, "param1", params[0].Value)
+
+	builds, err := client.GetAllBuildIds(ctx, "Job1_test")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(builds))
+
+	config, err := job1.GetConfig(ctx)
+	assert.Nil(t, err)
+	assert.Contains(t, config, "<project>")
+
+	disabled, err := job1.Disable(ctx)
+	assert.Nil(t, err)
+	assert.True(t, disabled)
+	enabled, err := job1.Enable(ctx)
+	assert.Nil(t, err)
+	assert.True(t, enabled)
+
+	jobCopy, err := job1.Copy(ctx, "Job1_test_copy")
+	assert.Nil(t, err)
+	assert.Equal(t, "Job1_test_copy", jobCopy.GetName())
+
+	for _, name := range []string{"Job1_test", "job2_test", "Job1_test_copy"} {
+		deleted, err := client.DeleteJob(ctx, name)
+		assert.Nil(t, err)
+		assert.True(t, deleted)
+	}
+}
+
+func TestCassetteNodeViewFolderFlow(t *testing.T) {
+	client, _ := newCassetteClient(t, "node_view_folder_flow")
+	ctx := context.Background()
+
+	node, err := client.CreateNodeWithOptions(ctx, gojenkins.CreateNodeOptions{
+		Name:         "node3_test",
+		NumExecutors: 1,
+		Description:  "Node 3 Description",
+		RemoteFS:     "/var/lib/jenkins",
+		Label:        "jdk7",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "node3_test", node.GetName())
+
+	label, err := client.GetLabel(ctx, "jdk7")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(label.GetNodes()))
+	assert.Equal(t, "node3_test", label.GetNodes()[0].NodeName)
+
+	nodes, err := client.GetAllNodes(ctx)
+	assert.Nil(t, err)
+	var names []string
+	for _, n := range nodes {
+		names = append(names, n.GetName())
+	}
+	assert.Contains(t, names, "node3_test")
+
+	view, err := client.CreateView(ctx, "test_list_view", gojenkins.LIST_VIEW)
+	assert.Nil(t, err)
+	assert.Equal(t, "test_list_view", view.GetName())
+	assert.Equal(t, 0, len(view.GetJobs()))
+
+	_, err = client.CreateFolder(ctx, "folder1_test")
+	assert.Nil(t, err)
+	_, err = client.CreateFolder(ctx, "folder2_test", "folder1_test")
+	assert.Nil(t, err)
+	folder, err := client.GetFolder(ctx, "folder2_test", "folder1_test")
+	assert.Nil(t, err)
+	assert.Equal(t, "folder2_test", folder.GetName())
+
+	_, err = client.Requester.Post(ctx, "/view/test_list_view/doDelete", nil, nil, nil)
+	assert.Nil(t, err)
+	deleted, err := client.DeleteJob(ctx, "folder1_test")
+	assert.Nil(t, err)
+	assert.True(t, deleted)
+	deleted, err = client.DeleteNode(ctx, "node3_test")
+	assert.Nil(t, err)
+	assert.True(t, deleted)
+}
+
+func TestCassetteRedactsAndReplays(t *testing.T) {
+	server := jenkinstest.NewServer(jenkinstest.WithUser("admin", "s3cret"))
+	defer server.Close()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder, err := jenkinstest.NewCassette(path, jenkinstest.ModeRecord, nil)
+	assert.Nil(t, err)
+	client, err := gojenkins.NewClient(server.URL,
+		gojenkins.WithHTTPClient(recorder.Client()), gojenkins.WithBasicAuth("admin", "s3cret"))
+	assert.Nil(t, err)
+	_, err = client.CreateJob(ctx, jenkinstest.FreeStyleConfig, "app")
+	assert.Nil(t, err)
+	_, err = client.Requester.GetJSON(ctx, "/job/app", &gojenkins.JobResponse{}, map[string]string{"depth": "1", "token": "abc"})
+	assert.Nil(t, err)
+	assert.Nil(t, recorder.Save())
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "fake-crumb")
+	assert.NotContains(t, string(data), "abc")
+	assert.NotContains(t, string(data), "Basic ")
+
+	player, err := jenkinstest.NewCassette(path, jenkinstest.ModeReplay, nil)
+	assert.Nil(t, err)
+	client, err = gojenkins.NewClient(replayURL, gojenkins.WithHTTPClient(player.Client()))
+	assert.Nil(t, err)
+	job, err := client.GetJob(ctx, "app")
+	assert.Nil(t, err)
+	assert.Equal(t, "app", job.GetName())
+
+	// Queries match regardless of their order and of redacted values.
+	req, _ := http.NewRequest(http.MethodGet, replayURL+"/job/app/api/json?token=other&depth=1", nil)
+	resp, err := player.RoundTrip(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	req, _ = http.NewRequest(http.MethodGet, replayURL+"/job/missing/api/json", nil)
+	_, err = player.RoundTrip(req)
+	assert.True(t, errors.Is(err, jenkinstest.ErrNoInteraction))
+	assert.True(t, strings.Contains(err.Error(), "/job/missing/api/json"))
+}
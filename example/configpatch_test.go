@@ -0,0 +1,156 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/stretchr/testify/assert"
+)
+
+const patchedConfig = `<?xml version='1.1' encoding='UTF-8'?>
+<project>
+  <!-- managed by hand -->
+  <description>old</description>
+  <properties/>
+  <scm class="hudson.scm.NullSCM"/>
+  <disabled>false</disabled>
+  <builders>
+    <hudson.tasks.Shell>
+      <command>make</command>
+    </hudson.tasks.Shell>
+    <hudson.tasks.Shell>
+      <command>make test</command>
+    </hudson.tasks.Shell>
+  </builders>
+  <publishers/>
+</project>
+`
+
+func TestPatchConfig(t *testing.T) {
+	patched, err := gojenkins.PatchConfig(patchedConfig,
+		gojenkins.SetText("description", "builds <app> & docs"),
+		gojenkins.SetText("builders/hudson.tasks.Shell[2]/command", "make check"),
+		gojenkins.SetAttr("scm", "plugin", "git@5.2.1"),
+		gojenkins.InsertElement("properties", `<jenkins.model.BuildDiscarderProperty><strategy class="hudson.tasks.LogRotator"><numToKeep>5</numToKeep></strategy></jenkins.model.BuildDiscarderProperty>`),
+		gojenkins.InsertElement("builders", `<hudson.tasks.Shell><command>make dist</command></hudson.tasks.Shell>`),
+		gojenkins.RemoveElement("publishers"),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, `<?xml version='1.1' encoding='UTF-8'?>
+<project>
+  <!-- managed by hand -->
+  <description>builds &lt;app&gt; &amp; docs</description>
+  <properties>
+    <jenkins.model.BuildDiscarderProperty><strategy class="hudson.tasks.LogRotator"><numToKeep>5</numToKeep></strategy></jenkins.model.BuildDiscarderProperty>
+  </properties>
+  <scm class="hudson.scm.NullSCM" plugin="git@5.2.1"/>
+  <disabled>false</disabled>
+  <builders>
+    <hudson.tasks.Shell>
+      <command>make</command>
+    </hudson.tasks.Shell>
+    <hudson.tasks.Shell>
+      <command>make check</command>
+    </hudson.tasks.Shell>
+    <hudson.tasks.Shell><command>make dist</command></hudson.tasks.Shell>
+  </builders>
+</project>
+`, patched)
+
+	diff := gojenkins.ConfigDiff("config.xml", patchedConfig, patched)
+	assert.True(t, strings.HasPrefix(diff, "--- a/config.xml\n+++ b/config.xml\n@@ "))
+	assert.Contains(t, diff, "\n-  <description>old</description>\n")
+	assert.Contains(t, diff, "\n+  <description>builds &lt;app&gt; &amp; docs</description>\n")
+	assert.Contains(t, diff, "\n-  <publishers/>\n")
+	assert.Equal(t, "", gojenkins.ConfigDiff("config.xml", patched, patched))
+
+	for _, op := range []gojenkins.ConfigOp{
+		gojenkins.SetText("triggers", "x"),
+		gojenkins.SetText("builders", "x"),
+		gojenkins.SetText("builders/hudson.tasks.Shell[3]/command", "x"),
+		gojenkins.InsertElement("builders", "<unclosed>"),
+		gojenkins.RemoveElement(""),
+	} {
+		_, err := gojenkins.PatchConfig(patchedConfig, op)
+		assert.NotNil(t, err, op.String())
+	}
+	_, err = gojenkins.PatchConfig("<project><description></project>", gojenkins.SetText("description", "x"))
+	assert.NotNil(t, err)
+}
+
+func TestJobPatchConfig(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+	assert.Nil(t, server.AddFolder("team"))
+	assert.Nil(t, server.AddJob("team/deploy", patchedConfig))
+	job, err := client.GetJob(ctx, "team/deploy")
+	assert.Nil(t, err)
+
+	patch, err := job.PatchConfig(ctx, gojenkins.PatchConfigOptions{DryRun: true}, gojenkins.SetText("disabled", "true"))
+	assert.Nil(t, err)
+	assert.False(t, patch.Written)
+	assert.Contains(t, patch.Diff, "--- a/job/team/job/deploy/config.xml\n")
+	stored, _ := server.Job("team/deploy")
+	assert.False(t, stored.Disabled)
+
+	patch, err = job.PatchConfig(ctx, gojenkins.PatchConfigOptions{Optimistic: true}, gojenkins.SetText("disabled", "true"))
+	assert.Nil(t, err)
+	assert.True(t, patch.Written)
+	stored, _ = server.Job("team/deploy")
+	assert.True(t, stored.Disabled)
+	assert.Equal(t, patch.After, stored.Config)
+
+	patch, err = job.PatchConfig(ctx, gojenkins.PatchConfigOptions{}, gojenkins.SetText("disabled", "true"))
+	assert.Nil(t, err)
+	assert.False(t, patch.Written)
+	assert.Equal(t, "", patch.Diff)
+
+	// Another client edits the job between the two reads of the optimistic patch.
+	other := newFakeClient(t, server)
+	reads := 0
+	racing := newFakeClient(t, server, gojenkins.WithMiddleware(func(next gojenkins.Handler) gojenkins.Handler {
+		return func(ctx context.Context, ar *gojenkins.APIRequest) (*http.Response, error) {
+			if ar.Method == http.MethodGet && strings.Contains(ar.Endpoint, "/config.xml") {
+				if reads++; reads == 2 {
+					otherJob, err := other.GetJob(ctx, "team/deploy")
+					assert.Nil(t, err)
+					_, err = otherJob.PatchConfig(ctx, gojenkins.PatchConfigOptions{}, gojenkins.SetText("description", "theirs"))
+					assert.Nil(t, err)
+				}
+			}
+			return next(ctx, ar)
+		}
+	}))
+	job, err = racing.GetJob(ctx, "team/deploy")
+	assert.Nil(t, err)
+	patch, err = job.PatchConfig(ctx, gojenkins.PatchConfigOptions{Optimistic: true}, gojenkins.SetText("description", "ours"))
+	assert.True(t, errors.Is(err, gojenkins.ErrConfigChanged))
+	assert.False(t, patch.Written)
+	stored, _ = server.Job("team/deploy")
+	assert.Equal(t, "theirs", stored.Description)
+
+	// A cached config.xml is not patched over an edit made by another client.
+	cached := newFakeClient(t, server, gojenkins.WithCache(gojenkins.CacheConfig{TTL: time.Hour}))
+	job, err = cached.GetJob(ctx, "team/deploy")
+	assert.Nil(t, err)
+	_, err = job.GetConfig(ctx)
+	assert.Nil(t, err)
+	otherJob, err := other.GetJob(ctx, "team/deploy")
+	assert.Nil(t, err)
+	_, err = otherJob.PatchConfig(ctx, gojenkins.PatchConfigOptions{}, gojenkins.SetText("description", "edited"))
+	assert.Nil(t, err)
+	patch, err = job.PatchConfig(ctx, gojenkins.PatchConfigOptions{Optimistic: true}, gojenkins.SetText("disabled", "false"))
+	assert.Nil(t, err)
+	assert.True(t, patch.Written)
+	stored, _ = server.Job("team/deploy")
+	assert.Equal(t, "edited", stored.Description)
+	assert.False(t, stored.Disabled)
+}
@@ -0,0 +1,58 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	start := time.Now()
+	_, err := client.GetJob(ctx, "job1")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestRequestCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	_, err := client.GetQueue(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestGetBuildFromQueueIDDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The queue item never leaves the quiet period.
+		w.Write([]byte(`{"id":42,"why":"In the quiet period"}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	_, err := client.GetBuildFromQueueID(ctx, client.GetJobObj(ctx, "job1"), 42)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,97 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// crumbServer issues crumbs bound to a session cookie and rejects POSTs without a matching pair.
+type crumbServer struct {
+	mu         sync.Mutex
+	generation int
+	issued     int
+	posts      int
+}
+
+func (c *crumbServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	session := fmt.Sprintf("session-%d", c.generation)
+	value := fmt.Sprintf("crumb-%d", c.generation)
+
+	if r.URL.Path == "/crumbIssuer/api/json" {
+		c.issued++
+		http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: session, Path: "/"})
+		fmt.Fprintf(w, `{"crumb":%q,"crumbRequestField":"Jenkins-Crumb"}`, value)
+		return
+	}
+
+	cookie, err := r.Cookie("JSESSIONID")
+	if err != nil || cookie.Value != session || r.Header.Get("Jenkins-Crumb") != value {
+		http.Error(w, "No valid crumb was included in the request", http.StatusForbidden)
+		return
+	}
+	c.posts++
+}
+
+// expire simulates the web session timing out on the server.
+func (c *crumbServer) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+}
+
+func TestCrumbCachedPerSession(t *testing.T) {
+	crumbs := &crumbServer{}
+	server := httptest.NewServer(crumbs)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	for i := 0; i < 3; i++ {
+		ok, err := client.GetJobObj(ctx, "job1").Disable(ctx)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 1, crumbs.issued)
+	assert.Equal(t, 3, crumbs.posts)
+}
+
+func TestCrumbRefreshedWhenRejected(t *testing.T) {
+	crumbs := &crumbServer{}
+	server := httptest.NewServer(crumbs)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	_, err := client.GetJobObj(ctx, "job1").Disable(ctx)
+	assert.Nil(t, err)
+
+	crumbs.expire()
+	ok, err := client.GetJobObj(ctx, "job1").Enable(ctx)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, crumbs.issued)
+	assert.Equal(t, 2, crumbs.posts)
+}
+
+func TestCrumbsDisabled(t *testing.T) {
+	crumbs := &crumbServer{}
+	server := httptest.NewServer(crumbs)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	client.Requester.DisableCrumbs = true
+	_, err := client.GetJobObj(ctx, "job1").Enable(ctx)
+	assert.True(t, errors.Is(err, gojenkins.ErrCrumbRejected))
+	assert.Equal(t, 0, crumbs.issued)
+}
@@ -0,0 +1,77 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDecodeServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/login/api/json":
+			w.Header().Set("Content-Type", "text/html;charset=utf-8")
+			w.Write([]byte(`<html><body>Sign in to Jenkins</body></html>`))
+		case "/job/broken/api/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name": 42}`))
+		case "/job/drift/api/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"drift","newField":true}`))
+		default:
+			w.Write([]byte(`<p>posted</p>`))
+		}
+	}))
+}
+
+func TestDecodeHTMLResponse(t *testing.T) {
+	server := newDecodeServer()
+	defer server.Close()
+
+	_, err := gojenkins.CreateJenkins(server.Client(), server.URL).GetJob(context.Background(), "login")
+	assert.True(t, errors.Is(err, gojenkins.ErrHTMLResponse))
+	var decodeErr *gojenkins.DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, "/job/login/api/json", decodeErr.Endpoint)
+	assert.Equal(t, "text/html;charset=utf-8", decodeErr.ContentType)
+}
+
+func TestDecodeSchemaMismatch(t *testing.T) {
+	server := newDecodeServer()
+	defer server.Close()
+
+	_, err := gojenkins.CreateJenkins(server.Client(), server.URL).GetJob(context.Background(), "broken")
+	var decodeErr *gojenkins.DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Contains(t, err.Error(), "/job/broken/api/json (application/json)")
+}
+
+func TestDecodeStrict(t *testing.T) {
+	server := newDecodeServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	_, err := gojenkins.CreateJenkins(server.Client(), server.URL).GetJob(ctx, "drift")
+	assert.Nil(t, err)
+
+	client, err := gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(server.Client()), gojenkins.WithStrictJSON())
+	assert.Nil(t, err)
+	_, err = client.GetJob(ctx, "drift")
+	assert.Contains(t, err.Error(), `unknown field "newField"`)
+}
+
+func TestDecodeSkipsFormPostResponses(t *testing.T) {
+	server := newDecodeServer()
+	defer server.Close()
+
+	client, err := gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(server.Client()), gojenkins.WithoutCrumbs())
+	assert.Nil(t, err)
+	ctx := context.Background()
+	_, err = client.GetJobObj(ctx, "job1").Disable(ctx)
+	assert.Nil(t, err)
+}
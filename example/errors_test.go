@@ -0,0 +1,87 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIErrorNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	_, err := client.GetJob(ctx, "missing")
+	assert.True(t, errors.Is(err, gojenkins.ErrNotFound))
+
+	var apiErr *gojenkins.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "GET", apiErr.Method)
+	assert.Equal(t, "/job/missing/api/json", apiErr.Endpoint)
+}
+
+func TestAPIErrorXErrorHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/crumbIssuer/api/json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Error", "A job already exists with the name 'job1'")
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	_, err := client.CreateJob(ctx, "<project/>", "job1")
+
+	var apiErr *gojenkins.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "POST", apiErr.Method)
+	assert.Equal(t, "A job already exists with the name 'job1'", apiErr.Message)
+	assert.False(t, errors.Is(err, gojenkins.ErrNotFound))
+}
+
+func TestAPIErrorCrumbRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/crumbIssuer/api/json" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, "No valid crumb was included in the request", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	_, err := client.DeleteJob(ctx, "job1")
+	assert.True(t, errors.Is(err, gojenkins.ErrCrumbRejected))
+	assert.True(t, errors.Is(err, gojenkins.ErrForbidden))
+	assert.False(t, errors.Is(err, gojenkins.ErrUnauthorized))
+}
+
+func TestAPIErrorConflict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/crumbIssuer/api/json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	credentials := gojenkins.CredentialsManager{J: client}
+	err := credentials.Add(ctx, "_", gojenkins.StringCredentials{ID: "secret", Scope: "GLOBAL", Secret: "s3cr3t"})
+	assert.True(t, errors.Is(err, gojenkins.ErrConflict))
+}
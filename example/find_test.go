@@ -0,0 +1,93 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func findJobs(t *testing.T, client *gojenkins.Jenkins, selector gojenkins.Selector) []string {
+	var names []string
+	for match := range client.FindJobs(context.Background(), selector) {
+		assert.Nil(t, match.Err)
+		names = append(names, match.FullName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestFindJobs(t *testing.T) {
+	now := time.Now()
+	clock := now.Add(-100 * 24 * time.Hour)
+	server := jenkinstest.NewServer(jenkinstest.WithClock(func() time.Time { return clock }))
+	defer server.Close()
+	client := newFakeClient(t, server)
+	labelled := strings.Replace(jenkinstest.FreeStyleConfig, "<canRoam>true</canRoam>", "<assignedNode>linux &amp;&amp; (docker || podman)</assignedNode>\n  <canRoam>false</canRoam>", 1)
+	parameterized := strings.Replace(jenkinstest.PipelineConfig, "<properties/>", `<properties>
+    <hudson.model.ParametersDefinitionProperty>
+      <parameterDefinitions>
+        <hudson.model.StringParameterDefinition>
+          <name>TARGET</name>
+        </hudson.model.StringParameterDefinition>
+      </parameterDefinitions>
+    </hudson.model.ParametersDefinitionProperty>
+  </properties>`, 1)
+	disabled := strings.Replace(jenkinstest.PipelineConfig, "<disabled>false</disabled>", "<disabled>true</disabled>", 1)
+
+	assert.Nil(t, server.AddFolder("team-x"))
+	assert.Nil(t, server.AddFolder("team-x/legacy"))
+	assert.Nil(t, server.AddFolder("team-y"))
+	assert.Nil(t, server.AddJob("team-x/old-deploy", disabled))
+	assert.Nil(t, server.AddJob("team-x/legacy/old-build", disabled))
+	assert.Nil(t, server.AddJob("team-x/new-deploy", parameterized))
+	assert.Nil(t, server.AddJob("team-x/build", labelled))
+	assert.Nil(t, server.AddJob("team-y/deploy", disabled))
+	assert.Nil(t, server.AddJob("top", jenkinstest.FreeStyleConfig))
+	_, err := server.AddBuild("team-x/legacy/old-build", jenkinstest.ResultSuccess, "")
+	assert.Nil(t, err)
+	clock = now
+	_, err = server.AddBuild("team-x/new-deploy", jenkinstest.ResultFailure, "")
+	assert.Nil(t, err)
+	_, err = server.AddBuild("top", jenkinstest.ResultSuccess, "")
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"team-x/build", "team-x/legacy/old-build", "team-x/new-deploy", "team-x/old-deploy", "team-y/deploy", "top"},
+		findJobs(t, client, gojenkins.Selector{}))
+	// All disabled pipeline jobs under team-x not built in 90 days.
+	assert.Equal(t, []string{"team-x/legacy/old-build", "team-x/old-deploy"}, findJobs(t, client, gojenkins.Selector{
+		Folder:      "team-x",
+		Classes:     []string{gojenkins.ClassWorkflowJob},
+		Disabled:    true,
+		NotBuiltFor: 90 * 24 * time.Hour,
+	}))
+	assert.Equal(t, []string{"team-x/new-deploy", "team-x/old-deploy"}, findJobs(t, client, gojenkins.Selector{Name: "team-x/*-deploy"}))
+	assert.Equal(t, []string{"team-x/new-deploy", "team-x/old-deploy", "team-y/deploy"}, findJobs(t, client, gojenkins.Selector{NameRegexp: regexp.MustCompile(`deploy$`)}))
+	assert.Equal(t, []string{"team-x/new-deploy"}, findJobs(t, client, gojenkins.Selector{Colors: []string{"red"}}))
+	assert.Equal(t, []string{"team-x/new-deploy", "top"}, findJobs(t, client, gojenkins.Selector{BuiltWithin: time.Hour, Buildable: true}))
+	assert.Equal(t, []string{"team-x/new-deploy"}, findJobs(t, client, gojenkins.Selector{Parameters: []string{"TARGET"}}))
+	assert.Equal(t, []string{"team-x/build"}, findJobs(t, client, gojenkins.Selector{Labels: []string{"linux", "podman"}}))
+	assert.Nil(t, findJobs(t, client, gojenkins.Selector{Labels: []string{"windows"}}))
+	assert.Equal(t, []string{"team-x/build", "team-x/new-deploy", "team-x/old-deploy"}, findJobs(t, client, gojenkins.Selector{Folder: "team-x", MaxDepth: 1}))
+
+	var matches []gojenkins.JobMatch
+	for match := range client.FindJobs(context.Background(), gojenkins.Selector{Folder: "missing"}) {
+		matches = append(matches, match)
+	}
+	assert.Equal(t, 1, len(matches))
+	assert.True(t, errors.Is(matches[0].Err, gojenkins.ErrNotFound))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	found := client.FindJobs(ctx, gojenkins.Selector{})
+	<-found
+	cancel()
+	for range found {
+	}
+}
@@ -0,0 +1,368 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/stretchr/testify/assert"
+)
+
+const parameterizedConfig = `<?xml version='1.1' encoding='UTF-8'?>
+<project>
+  <description>deploys the app</description>
+  <properties>
+    <hudson.model.ParametersDefinitionProperty>
+      <parameterDefinitions>
+        <hudson.model.StringParameterDefinition>
+          <name>TARGET</name>
+          <defaultValue>staging</defaultValue>
+        </hudson.model.StringParameterDefinition>
+      </parameterDefinitions>
+    </hudson.model.ParametersDefinitionProperty>
+  </properties>
+  <disabled>false</disabled>
+</project>`
+
+func newFakeClient(t *testing.T, server *jenkinstest.Server, opts ...gojenkins.Option) *gojenkins.Jenkins {
+	client, err := server.NewClient(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestFakeServerJobs(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+
+	job, err := client.CreateJobWithOptions(ctx, jenkinstest.FreeStyleConfig, gojenkins.CreateJobOptions{Name: "app"})
+	assert.Nil(t, err)
+	assert.Equal(t, jenkinstest.FreeStyleClass, job.Raw.Class)
+	assert.Equal(t, "notbuilt", job.Raw.Color)
+
+	_, err = client.CreateJobWithOptions(ctx, jenkinstest.FreeStyleConfig, gojenkins.CreateJobOptions{Name: "app"})
+	var apiErr *gojenkins.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Contains(t, apiErr.Message, "already exists")
+
+	config := strings.Replace(jenkinstest.FreeStyleConfig, "<description></description>", "<description>builds the app</description>", 1)
+	assert.Nil(t, job.UpdateConfig(ctx, config))
+	assert.Equal(t, "builds the app", job.GetDescription())
+	stored, err := job.GetConfig(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, config, stored)
+
+	_, err = job.Disable(ctx)
+	assert.Nil(t, err)
+	enabled, err := job.IsEnabled(ctx)
+	assert.Nil(t, err)
+	assert.False(t, enabled)
+
+	_, err = job.Copy(ctx, "app-copy")
+	assert.Nil(t, err)
+	_, err = client.GetJob(ctx, "app-copy")
+	assert.Nil(t, err)
+	_, err = job.Rename(ctx, "app-renamed")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app-copy", "app-renamed"}, server.Jobs())
+
+	_, err = client.DeleteJob(ctx, "app-copy")
+	assert.Nil(t, err)
+	_, err = client.GetJob(ctx, "app-copy")
+	assert.True(t, errors.Is(err, gojenkins.ErrNotFound))
+
+	_, err = client.CreateFolder(ctx, "team")
+	assert.Nil(t, err)
+	_, err = client.CreateJobInFolder(ctx, jenkinstest.PipelineConfig, "deploy", "team")
+	assert.Nil(t, err)
+	folder, err := client.GetFolder(ctx, "team")
+	assert.Nil(t, err)
+	if assert.Len(t, folder.Raw.Jobs, 1) {
+		assert.Equal(t, "deploy", folder.Raw.Jobs[0].Name)
+		assert.Equal(t, jenkinstest.PipelineClass, folder.Raw.Jobs[0].Class)
+	}
+	names, err := client.GetAllJobNames(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, names, 2)
+}
+
+func TestFakeServerBuildProgression(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+	assert.Nil(t, server.AddJob("deploy", parameterizedConfig))
+
+	queueID, err := client.BuildJob(ctx, "deploy", map[string]string{"TARGET": "production"})
+	assert.Nil(t, err)
+	again, err := client.BuildJob(ctx, "deploy", map[string]string{"TARGET": "production"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), again, "a queued job is not invoked again")
+
+	task, err := client.GetQueueItem(ctx, queueID)
+	assert.Nil(t, err)
+	assert.Equal(t, "Waiting for next available executor", task.GetWhy())
+	assert.Equal(t, int64(0), task.Raw.Executable.Number)
+
+	number, err := server.StartBuild("deploy")
+	assert.Nil(t, err)
+	job, err := client.GetJob(ctx, "deploy")
+	assert.Nil(t, err)
+	build, err := client.GetBuildFromQueueID(ctx, job, queueID)
+	assert.Nil(t, err)
+	assert.Equal(t, number, build.GetBuildNumber())
+	assert.True(t, build.IsRunning(ctx))
+	assert.Equal(t, "TARGET", build.GetParameters()[0].Name)
+	assert.Equal(t, "production", build.GetParameters()[0].Value)
+
+	assert.Nil(t, server.AppendLog("deploy", number, "deploying\n"))
+	console, err := build.GetConsoleOutputFromIndex(ctx, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "deploying\n", console.Content)
+	assert.True(t, console.HasMoreText)
+
+	assert.Nil(t, server.AppendLog("deploy", number, "done\n"))
+	assert.Nil(t, server.FinishBuild("deploy", number, jenkinstest.ResultSuccess))
+	console, err = build.GetConsoleOutputFromIndex(ctx, console.Offset)
+	assert.Nil(t, err)
+	assert.Equal(t, "done\n", console.Content)
+	assert.False(t, console.HasMoreText)
+
+	_, err = build.Poll(ctx)
+	assert.Nil(t, err)
+	assert.False(t, build.IsRunning(ctx))
+	assert.Equal(t, gojenkins.STATUS_SUCCESS, build.GetResult())
+	assert.Equal(t, "deploying\ndone\n", build.GetConsoleOutput(ctx))
+
+	_, err = job.Poll(ctx)
+	assert.Nil(t, err)
+	last, err := job.GetLastSuccessfulBuild(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, number, last.GetBuildNumber())
+	assert.Equal(t, "blue", job.Raw.Color)
+}
+
+func TestFakeServerQueue(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+	assert.Nil(t, server.AddJob("nightly", jenkinstest.FreeStyleConfig))
+
+	queueID, err := client.BuildJob(ctx, "nightly", nil)
+	assert.Nil(t, err)
+	queue, err := client.GetQueue(ctx)
+	assert.Nil(t, err)
+	tasks := queue.GetTasksForJob("nightly")
+	if assert.Len(t, tasks, 1) {
+		_, err = tasks[0].Cancel(ctx)
+		assert.Nil(t, err)
+	}
+	assert.Empty(t, server.Queue())
+	item, ok := server.QueueItem(queueID)
+	assert.True(t, ok)
+	assert.True(t, item.Cancelled)
+
+	autoServer := jenkinstest.NewServer(jenkinstest.WithAutoStart())
+	defer autoServer.Close()
+	autoClient := newFakeClient(t, autoServer)
+	assert.Nil(t, autoServer.AddJob("nightly", jenkinstest.FreeStyleConfig))
+	queueID, err = autoClient.BuildJob(ctx, "nightly", nil)
+	assert.Nil(t, err)
+	task, err := autoClient.GetQueueItem(ctx, queueID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), task.Raw.Executable.Number)
+}
+
+func TestFakeServerNodes(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+
+	node, err := client.CreateNodeWithOptions(ctx, gojenkins.CreateNodeOptions{Name: "agent-1", NumExecutors: 4, Label: "linux docker"})
+	assert.Nil(t, err)
+	assert.True(t, node.Raw.Offline, "agents are offline until they connect")
+	assert.True(t, node.Raw.JnlpAgent)
+
+	assert.Nil(t, server.SetNodeOnline("agent-1", true))
+	online, err := node.IsOnline(ctx)
+	assert.Nil(t, err)
+	assert.True(t, online)
+
+	_, err = node.SetOffline(ctx, "maintenance")
+	assert.Nil(t, err)
+	stored, _ := server.Node("agent-1")
+	assert.True(t, stored.TemporarilyOffline)
+	assert.Equal(t, "maintenance", stored.OfflineMessage)
+
+	nodes, err := client.GetAllNodes(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, nodes, 2)
+	label, err := client.GetLabel(ctx, "docker")
+	assert.Nil(t, err)
+	if assert.Len(t, label.GetNodes(), 1) {
+		assert.Equal(t, "agent-1", label.GetNodes()[0].NodeName)
+	}
+
+	_, err = client.DeleteNode(ctx, "agent-1")
+	assert.Nil(t, err)
+	_, err = client.GetNode(ctx, "agent-1")
+	assert.True(t, errors.Is(err, gojenkins.ErrNotFound))
+}
+
+func TestFakeServerCredentials(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+	cm := gojenkins.CredentialsManager{J: client}
+
+	creds := gojenkins.UsernameCredentials{ID: "deploy-user", Scope: "GLOBAL", Username: "deployer", Password: "s3cret"}
+	assert.Nil(t, cm.Add(ctx, "_", creds))
+	err := cm.Add(ctx, "_", creds)
+	assert.True(t, errors.Is(err, gojenkins.ErrConflict))
+
+	ids, err := cm.List(ctx, "_")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"deploy-user"}, ids)
+
+	var fetched gojenkins.UsernameCredentials
+	assert.Nil(t, cm.GetSingle(ctx, "_", "deploy-user", &fetched))
+	assert.Equal(t, "deployer", fetched.Username)
+	assert.Equal(t, "", fetched.Password, "Jenkins does not hand out secrets")
+	stored, _ := server.Credential("", "_", "deploy-user")
+	assert.Contains(t, stored, "s3cret")
+
+	creds.Username = "release"
+	assert.Nil(t, cm.Update(ctx, "_", "deploy-user", creds))
+	assert.Nil(t, cm.GetSingle(ctx, "_", "deploy-user", &fetched))
+	assert.Equal(t, "release", fetched.Username)
+
+	assert.Nil(t, cm.Delete(ctx, "_", "deploy-user"))
+	ids, err = cm.List(ctx, "_")
+	assert.Nil(t, err)
+	assert.Empty(t, ids)
+
+	assert.Nil(t, server.AddFolder("team"))
+	folderCM := gojenkins.CredentialsManager{J: client, Folder: "team"}
+	assert.Nil(t, folderCM.Add(ctx, "_", gojenkins.StringCredentials{ID: "token", Scope: "GLOBAL", Secret: "abc"}))
+	_, ok := server.Credential("team", "_", "token")
+	assert.True(t, ok)
+}
+
+func TestFakeServerSecurity(t *testing.T) {
+	server := jenkinstest.NewServer(jenkinstest.WithUser("alice", "password"), jenkinstest.WithAPIToken("bot", "token"))
+	defer server.Close()
+	ctx := context.Background()
+
+	_, err := newFakeClient(t, server).Init(ctx)
+	assert.True(t, errors.Is(err, gojenkins.ErrUnauthorized))
+
+	alice := newFakeClient(t, server, gojenkins.WithBasicAuth("alice", "password"))
+	_, err = alice.Init(ctx)
+	assert.Nil(t, err)
+	assert.True(t, alice.Raw.UseSecurity)
+	assert.True(t, alice.Raw.UseCrumbs)
+	_, err = alice.CreateJobWithOptions(ctx, jenkinstest.FreeStyleConfig, gojenkins.CreateJobOptions{Name: "with-crumb"})
+	assert.Nil(t, err)
+
+	bot := newFakeClient(t, server, gojenkins.WithAPIToken("bot", "token"))
+	_, err = bot.CreateJobWithOptions(ctx, jenkinstest.FreeStyleConfig, gojenkins.CreateJobOptions{Name: "without-crumb"})
+	assert.Nil(t, err)
+
+	req, _ := http.NewRequest("POST", server.URL+"/job/with-crumb/disable", nil)
+	req.SetBasicAuth("alice", "password")
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestFakeServerPluginsAndPipelines(t *testing.T) {
+	server := jenkinstest.NewServer(jenkinstest.WithVersion("2.401.1"))
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+
+	_, err := client.Init(ctx)
+	assert.Nil(t, err)
+	assert.True(t, client.VersionAtLeast("2.401"))
+	assert.Nil(t, client.InstallPlugin(ctx, "git", "5.2.0"))
+	plugin, err := client.HasPlugin(ctx, "git")
+	assert.Nil(t, err)
+	if assert.NotNil(t, plugin) {
+		assert.Equal(t, "5.2.0", plugin.Version)
+	}
+	caps, err := client.ProbeCapabilities(ctx)
+	assert.Nil(t, err)
+	assert.True(t, caps.HasPlugin(gojenkins.PluginPipelineRESTAPI))
+
+	assert.Nil(t, server.AddJob("pipeline", jenkinstest.PipelineConfig))
+	_, err = client.BuildJob(ctx, "pipeline", nil)
+	assert.Nil(t, err)
+	number, err := server.StartBuild("pipeline")
+	assert.Nil(t, err)
+	assert.Nil(t, server.AddStage("pipeline", number, "Build", "SUCCESS", time.Second))
+	assert.Nil(t, server.AddStage("pipeline", number, "Test", "IN_PROGRESS", 0))
+	assert.Nil(t, server.FinishBuild("pipeline", number, jenkinstest.ResultFailure))
+
+	job, err := client.GetJob(ctx, "pipeline")
+	assert.Nil(t, err)
+	runs, err := job.GetPipelineRuns(ctx)
+	assert.Nil(t, err)
+	if assert.Len(t, runs, 1) {
+		assert.Equal(t, "FAILED", runs[0].Status)
+		if assert.Len(t, runs[0].Stages, 2) {
+			assert.Equal(t, "FAILED", runs[0].Stages[1].Status)
+		}
+	}
+	run, err := job.GetPipelineRun(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "/job/pipeline/1", run.Base)
+
+	bare := jenkinstest.NewServer(jenkinstest.WithPlugins())
+	defer bare.Close()
+	bareClient := newFakeClient(t, bare)
+	_, err = bareClient.ProbeCapabilities(ctx)
+	assert.Nil(t, err)
+	_, err = bareClient.CreateFolder(ctx, "team")
+	assert.True(t, errors.Is(err, gojenkins.ErrPluginRequired))
+}
+
+func TestFakeServerTree(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+	assert.Nil(t, server.AddJob("app", jenkinstest.FreeStyleConfig))
+	for i := 0; i < 3; i++ {
+		_, err := server.AddBuild("app", jenkinstest.ResultSuccess, "ok\n")
+		assert.Nil(t, err)
+	}
+
+	var out struct {
+		Name   string `json:"name"`
+		Color  string `json:"color"`
+		Builds []struct {
+			Number int64  `json:"number"`
+			URL    string `json:"url"`
+		} `json:"builds"`
+	}
+	tree := gojenkins.NewTree(gojenkins.Field("name"), gojenkins.Field("builds", gojenkins.Fields("number")...).First(2))
+	assert.Nil(t, client.Query(ctx, "/job/app", tree, &out))
+	assert.Equal(t, "app", out.Name)
+	assert.Equal(t, "", out.Color)
+	if assert.Len(t, out.Builds, 2) {
+		assert.Equal(t, int64(3), out.Builds[0].Number)
+		assert.Equal(t, "", out.Builds[0].URL)
+	}
+}
@@ -1,72 +1,103 @@
 package example
 
 import (
-	"fmt"
-	"github.com/reaperhero/client-jenkins-go/utils"
-	"github.com/sirupsen/logrus"
-	"os"
+	"context"
+	"io/ioutil"
 	"testing"
+
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/reaperhero/client-jenkins-go/utils"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestCreateJobInFolder(t *testing.T) {
-	job, err := jc.CreateJobInFolder(jc.Context, "<config></config>", "newJobName", "myFolder", "parentFolder") //JOB_DATA_XML JOB_NAME FOLDER_NAME
-	if err != nil {
-		logrus.Error(err)
-		os.Exit(1)
-	}
-	fmt.Println(job)
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
+	assert.Nil(t, server.AddFolder("myFolder"))
+	assert.Nil(t, server.AddFolder("myFolder/parentFolder"))
+
+	job, err := jc.CreateJobInFolder(jc.Context, jenkinstest.FreeStyleConfig, "newJobName", "myFolder", "parentFolder") //JOB_DATA_XML JOB_NAME FOLDER_NAME
+	assert.Nil(t, err)
+	assert.Equal(t, "myFolder/parentFolder/newJobName", job.Raw.FullName)
+	_, ok := server.Job("myFolder/parentFolder/newJobName")
+	assert.True(t, ok)
 }
 
 func TestCreateJob(t *testing.T) {
-	job, err := jc.CreateJob(jc.Context, "job.xml", "jobName")
-	if err != nil {
-		os.Exit(1)
-	}
-	fmt.Println(job)
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
+	config, err := ioutil.ReadFile("job.xml")
+	assert.Nil(t, err)
 
+	job, err := jc.CreateJob(jc.Context, string(config), "jobName")
+	assert.Nil(t, err)
+	assert.Equal(t, "jobName", job.GetName())
+	assert.Equal(t, "Some Job Description", job.GetDescription())
 }
 
 func TestDeleteJob(t *testing.T) {
-	_, _ = jc.DeleteJob(jc.Context, "jobName")
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
+	assert.Nil(t, server.AddJob("jobName", jenkinstest.FreeStyleConfig))
+
+	deleted, err := jc.DeleteJob(jc.Context, "jobName")
+	assert.Nil(t, err)
+	assert.True(t, deleted)
+	_, ok := server.Job("jobName")
+	assert.False(t, ok)
 }
 
 func TestEnableJob(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
+	assert.Nil(t, server.AddJob("job_id", jenkinstest.FreeStyleConfig))
+
 	job, err := jc.GetJob(jc.Context, "job_id")
-	if err != nil {
-		os.Exit(1)
-	}
-	_, _ = job.Enable(jc.Context)
+	assert.Nil(t, err)
+	_, err = job.Disable(jc.Context)
+	assert.Nil(t, err)
+	enabled, err := job.Enable(jc.Context)
+	assert.Nil(t, err)
+	assert.True(t, enabled)
+	stored, _ := server.Job("job_id")
+	assert.False(t, stored.Disabled)
 }
 
 func TestGetLastUnstableBuild(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
+	assert.Nil(t, server.AddJob("jobName", jenkinstest.FreeStyleConfig))
+	_, err := server.AddBuild("jobName", jenkinstest.ResultSuccess, "")
+	assert.Nil(t, err)
+	_, err = server.AddBuild("jobName", jenkinstest.ResultUnstable, "")
+	assert.Nil(t, err)
+
 	job, err := jc.GetJob(jc.Context, "jobName")
-	if err != nil {
-		logrus.Info("❌ unable to find the specific job")
-	}
+	assert.Nil(t, err)
 	build, err := job.GetLastBuild(jc.Context)
-	if err != nil {
-		logrus.Info("❌ unable to find the last unstable build job")
-	}
-
-	if len(build.Job.Raw.LastBuild.URL) > 0 {
-		fmt.Printf("Last unstable build Number: %d\n", build.Job.Raw.LastBuild.Number)
-		fmt.Printf("Last unstable build URL: %s\n", build.Job.Raw.LastBuild.URL)
-		fmt.Printf("Parameters: %s\n", build.GetParameters())
-	} else {
-		fmt.Printf("No last unstable build available for job: %s", "jobName")
-	}
+	assert.Nil(t, err)
+	assert.Equal(t, "UNSTABLE", build.GetResult())
+	assert.Equal(t, int64(2), build.Job.Raw.LastUnstableBuild.Number)
 }
 
 func TestShowAllJobs(t *testing.T) {
-	jobs, err := jc.GetAllJobs(jc.Context)
-	if err != nil {
-		logrus.Error(err)
-	}
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
+	assert.Nil(t, server.AddJob("a", jenkinstest.FreeStyleConfig))
+	assert.Nil(t, server.AddJob("b", jenkinstest.PipelineConfig))
+
+	jobs, err := jc.GetAllJobs(context.Background())
+	assert.Nil(t, err)
+	var names []string
 	for _, job := range jobs {
-		fmt.Printf("✅ %s\n", job.Raw.Name)
+		names = append(names, job.Raw.Name)
 		utils.ShowStatus(job.Raw.Color)
-		fmt.Printf("%s\n", job.Raw.Description)
-		fmt.Printf("%s\n", job.Raw.URL)
-		fmt.Printf("\n")
 	}
+	assert.Equal(t, []string{"a", "b"}, names)
 }
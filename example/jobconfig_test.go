@@ -0,0 +1,333 @@
+package example
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/stretchr/testify/assert"
+)
+
+const freeStyleJobXML = `<?xml version='1.1' encoding='UTF-8'?>
+<project>
+  <actions/>
+  <description>builds the "app"</description>
+  <keepDependencies>false</keepDependencies>
+  <properties>
+    <jenkins.model.BuildDiscarderProperty>
+      <strategy class="hudson.tasks.LogRotator">
+        <daysToKeep>7</daysToKeep>
+        <numToKeep>20</numToKeep>
+        <artifactDaysToKeep>-1</artifactDaysToKeep>
+        <artifactNumToKeep>-1</artifactNumToKeep>
+      </strategy>
+    </jenkins.model.BuildDiscarderProperty>
+    <com.sonyericsson.rebuild.RebuildSettings plugin="rebuild@1.34">
+      <autoRebuild>false</autoRebuild>
+    </com.sonyericsson.rebuild.RebuildSettings>
+    <hudson.model.ParametersDefinitionProperty>
+      <parameterDefinitions>
+        <hudson.model.StringParameterDefinition>
+          <name>TARGET</name>
+          <defaultValue>staging</defaultValue>
+          <trim>true</trim>
+        </hudson.model.StringParameterDefinition>
+        <hudson.model.ChoiceParameterDefinition>
+          <name>REGION</name>
+          <choices class="java.util.Arrays$ArrayList">
+            <a class="string-array">
+              <string>eu</string>
+              <string>us</string>
+            </a>
+          </choices>
+        </hudson.model.ChoiceParameterDefinition>
+      </parameterDefinitions>
+    </hudson.model.ParametersDefinitionProperty>
+  </properties>
+  <scm class="hudson.plugins.git.GitSCM" plugin="git@5.2.1">
+    <configVersion>2</configVersion>
+    <userRemoteConfigs>
+      <hudson.plugins.git.UserRemoteConfig>
+        <url>https://git.example.com/app.git</url>
+        <credentialsId>git</credentialsId>
+      </hudson.plugins.git.UserRemoteConfig>
+    </userRemoteConfigs>
+    <branches>
+      <hudson.plugins.git.BranchSpec>
+        <name>*/main</name>
+      </hudson.plugins.git.BranchSpec>
+    </branches>
+    <extensions/>
+  </scm>
+  <canRoam>true</canRoam>
+  <disabled>false</disabled>
+  <triggers>
+    <hudson.triggers.TimerTrigger>
+      <spec>H 2 * * *</spec>
+    </hudson.triggers.TimerTrigger>
+  </triggers>
+  <builders>
+    <hudson.tasks.Shell>
+      <command>make &amp;&amp; make test</command>
+      <configuredLocalRules/>
+    </hudson.tasks.Shell>
+    <org.jenkinsci.plugins.ant.Ant plugin="ant@1.0">
+      <targets>dist</targets>
+    </org.jenkinsci.plugins.ant.Ant>
+  </builders>
+  <publishers>
+    <hudson.tasks.Mailer plugin="mailer@1.0">
+      <recipients>team@example.com</recipients>
+    </hudson.tasks.Mailer>
+  </publishers>
+</project>`
+
+const pipelineJobXML = `<?xml version='1.1' encoding='UTF-8'?>
+<flow-definition plugin="workflow-job@1400">
+  <description></description>
+  <properties>
+    <org.jenkinsci.plugins.workflow.job.properties.PipelineTriggersJobProperty>
+      <triggers>
+        <hudson.triggers.SCMTrigger>
+          <spec>H/5 * * * *</spec>
+          <ignorePostCommitHooks>false</ignorePostCommitHooks>
+        </hudson.triggers.SCMTrigger>
+      </triggers>
+    </org.jenkinsci.plugins.workflow.job.properties.PipelineTriggersJobProperty>
+  </properties>
+  <definition class="org.jenkinsci.plugins.workflow.cps.CpsFlowDefinition" plugin="workflow-cps@3800">
+    <script>node {
+  sh 'echo "hello"'
+}</script>
+    <sandbox>true</sandbox>
+  </definition>
+  <triggers/>
+  <disabled>true</disabled>
+</flow-definition>`
+
+func TestParseFreeStyleJobConfig(t *testing.T) {
+	parsed, err := gojenkins.ParseJobConfig(freeStyleJobXML)
+	assert.Nil(t, err)
+	config, ok := parsed.(*gojenkins.FreeStyleJobConfig)
+	assert.True(t, ok)
+	assert.Equal(t, `builds the "app"`, config.Description)
+	assert.Equal(t, 20, config.Properties.BuildDiscarder.Strategy.NumToKeep)
+	parameters := config.Properties.Parameters.Definitions.Parameters
+	assert.Equal(t, 2, len(parameters))
+	assert.Equal(t, gojenkins.StringParameter, parameters[0].XMLName.Local)
+	assert.Equal(t, "staging", parameters[0].DefaultValue)
+	assert.Equal(t, []string{"eu", "us"}, parameters[1].Choices.Array.Values)
+	assert.Equal(t, gojenkins.ClassGitSCM, config.SCM.Class)
+	assert.Equal(t, "https://git.example.com/app.git", config.SCM.Remotes[0].URL)
+	assert.Equal(t, "*/main", config.SCM.Branches[0].Name)
+	assert.Equal(t, "H 2 * * *", config.Triggers.Timer.Spec)
+	assert.Equal(t, 2, len(config.Builders.Steps))
+	assert.Equal(t, "make && make test", config.Builders.Steps[0].Command)
+
+	data, err := gojenkins.MarshalJobConfig(config)
+	assert.Nil(t, err)
+	for _, unknown := range []string{
+		`<com.sonyericsson.rebuild.RebuildSettings plugin="rebuild@1.34">
+      <autoRebuild>false</autoRebuild>
+    </com.sonyericsson.rebuild.RebuildSettings>`,
+		`<org.jenkinsci.plugins.ant.Ant plugin="ant@1.0">
+      <targets>dist</targets>
+    </org.jenkinsci.plugins.ant.Ant>`,
+		`<hudson.tasks.Mailer plugin="mailer@1.0">
+      <recipients>team@example.com</recipients>
+    </hudson.tasks.Mailer>`,
+		`<trim>true</trim>`,
+		`<canRoam>true</canRoam>`,
+		`<description>builds the "app"</description>`,
+	} {
+		assert.Contains(t, data, unknown)
+	}
+
+	again, err := gojenkins.ParseJobConfig(data)
+	assert.Nil(t, err)
+	data2, err := gojenkins.MarshalJobConfig(again)
+	assert.Nil(t, err)
+	assert.Equal(t, data, data2)
+}
+
+// jenkinsFreeStyleXML is a config.xml saved by Jenkins 2.426.3.
+const jenkinsFreeStyleXML = `<?xml version='1.1' encoding='UTF-8'?>
+<project>
+  <actions/>
+  <description>Builds and publishes the app.&#xd;
+Owned by the platform team.</description>
+  <keepDependencies>false</keepDependencies>
+  <properties>
+    <jenkins.model.BuildDiscarderProperty>
+      <strategy class="hudson.tasks.LogRotator">
+        <daysToKeep>-1</daysToKeep>
+        <numToKeep>30</numToKeep>
+        <artifactDaysToKeep>-1</artifactDaysToKeep>
+        <artifactNumToKeep>-1</artifactNumToKeep>
+      </strategy>
+    </jenkins.model.BuildDiscarderProperty>
+    <com.sonyericsson.rebuild.RebuildSettings plugin="rebuild@332.va_1ee476d8f6d">
+      <autoRebuild>false</autoRebuild>
+      <rebuildDisabled>false</rebuildDisabled>
+    </com.sonyericsson.rebuild.RebuildSettings>
+    <hudson.model.ParametersDefinitionProperty>
+      <parameterDefinitions>
+        <hudson.model.StringParameterDefinition>
+          <name>TARGET</name>
+          <description>Where to deploy</description>
+          <defaultValue>staging</defaultValue>
+          <trim>true</trim>
+        </hudson.model.StringParameterDefinition>
+      </parameterDefinitions>
+    </hudson.model.ParametersDefinitionProperty>
+  </properties>
+  <scm class="hudson.plugins.git.GitSCM" plugin="git@5.2.1">
+    <configVersion>2</configVersion>
+    <userRemoteConfigs>
+      <hudson.plugins.git.UserRemoteConfig>
+        <url>https://git.example.com/app.git</url>
+      </hudson.plugins.git.UserRemoteConfig>
+    </userRemoteConfigs>
+    <branches>
+      <hudson.plugins.git.BranchSpec>
+        <name>*/main</name>
+      </hudson.plugins.git.BranchSpec>
+    </branches>
+    <doGenerateSubmoduleConfigurations>false</doGenerateSubmoduleConfigurations>
+    <submoduleCfg class="empty-list"/>
+    <extensions/>
+  </scm>
+  <canRoam>true</canRoam>
+  <disabled>false</disabled>
+  <blockBuildWhenDownstreamBuilding>false</blockBuildWhenDownstreamBuilding>
+  <blockBuildWhenUpstreamBuilding>false</blockBuildWhenUpstreamBuilding>
+  <triggers/>
+  <concurrentBuild>false</concurrentBuild>
+  <builders>
+    <hudson.tasks.Shell>
+      <command>./gradlew build -Ptarget=&quot;$TARGET&quot;</command>
+      <configuredLocalRules/>
+    </hudson.tasks.Shell>
+  </builders>
+  <publishers>
+    <hudson.tasks.ArtifactArchiver>
+      <artifacts>build/libs/*.jar</artifacts>
+      <allowEmptyArchive>false</allowEmptyArchive>
+      <onlyIfSuccessful>false</onlyIfSuccessful>
+      <fingerprint>false</fingerprint>
+      <defaultExcludes>true</defaultExcludes>
+      <caseSensitive>true</caseSensitive>
+      <followSymlinks>false</followSymlinks>
+    </hudson.tasks.ArtifactArchiver>
+  </publishers>
+  <buildWrappers/>
+</project>`
+
+func TestJobConfigRoundTrip(t *testing.T) {
+	for _, data := range []string{jenkinsFreeStyleXML, freeStyleJobXML, pipelineJobXML} {
+		config, err := gojenkins.ParseJobConfig(data)
+		assert.Nil(t, err)
+		marshaled, err := gojenkins.MarshalJobConfig(config)
+		assert.Nil(t, err)
+		assert.Equal(t, data, marshaled)
+	}
+
+	parsed, err := gojenkins.ParseJobConfig(jenkinsFreeStyleXML)
+	assert.Nil(t, err)
+	config := parsed.(*gojenkins.FreeStyleJobConfig)
+	config.Properties.BuildDiscarder.Strategy.NumToKeep = 10
+	config.Properties.AddParameter(gojenkins.NewParameter(gojenkins.StringParameter, "REGION", "eu", ""))
+	config.Triggers.Timer = &gojenkins.TimerTrigger{Spec: "H 2 * * *"}
+	marshaled, err := gojenkins.MarshalJobConfig(config)
+	assert.Nil(t, err)
+	assert.Equal(t, `--- a/config.xml
++++ b/config.xml
+@@ -8,7 +8,7 @@
+     <jenkins.model.BuildDiscarderProperty>
+       <strategy class="hudson.tasks.LogRotator">
+         <daysToKeep>-1</daysToKeep>
+-        <numToKeep>30</numToKeep>
++        <numToKeep>10</numToKeep>
+         <artifactDaysToKeep>-1</artifactDaysToKeep>
+         <artifactNumToKeep>-1</artifactNumToKeep>
+       </strategy>
+@@ -24,6 +24,10 @@
+           <description>Where to deploy</description>
+           <defaultValue>staging</defaultValue>
+           <trim>true</trim>
++        </hudson.model.StringParameterDefinition>
++        <hudson.model.StringParameterDefinition>
++          <name>REGION</name>
++          <defaultValue>eu</defaultValue>
+         </hudson.model.StringParameterDefinition>
+       </parameterDefinitions>
+     </hudson.model.ParametersDefinitionProperty>
+@@ -48,7 +52,11 @@
+   <disabled>false</disabled>
+   <blockBuildWhenDownstreamBuilding>false</blockBuildWhenDownstreamBuilding>
+   <blockBuildWhenUpstreamBuilding>false</blockBuildWhenUpstreamBuilding>
+-  <triggers/>
++  <triggers>
++    <hudson.triggers.TimerTrigger>
++      <spec>H 2 * * *</spec>
++    </hudson.triggers.TimerTrigger>
++  </triggers>
+   <concurrentBuild>false</concurrentBuild>
+   <builders>
+     <hudson.tasks.Shell>
+`, gojenkins.ConfigDiff("config.xml", jenkinsFreeStyleXML, marshaled))
+}
+
+func TestParsePipelineJobConfig(t *testing.T) {
+	parsed, err := gojenkins.ParseJobConfig(pipelineJobXML)
+	assert.Nil(t, err)
+	config, ok := parsed.(*gojenkins.PipelineJobConfig)
+	assert.True(t, ok)
+	assert.True(t, config.Disabled)
+	assert.Equal(t, gojenkins.ClassCpsFlowDefinition, config.Definition.Class)
+	assert.True(t, config.Definition.Sandbox)
+	assert.Equal(t, "H/5 * * * *", config.Properties.PipelineTriggers.Triggers.SCM.Spec)
+
+	data, err := gojenkins.MarshalJobConfig(config)
+	assert.Nil(t, err)
+	assert.Contains(t, data, `<flow-definition plugin="workflow-job@1400">`)
+	assert.Contains(t, data, "<script>node {\n  sh 'echo \"hello\"'\n}</script>")
+
+	_, err = gojenkins.ParseJobConfig(`<matrix-project/>`)
+	assert.NotNil(t, err)
+}
+
+func TestTypedConfig(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+
+	config := &gojenkins.PipelineJobConfig{
+		Description: "deploys the app",
+		Definition:  gojenkins.NewSCMPipeline(gojenkins.NewGitSCM("https://git.example.com/app.git", "git", "*/main"), "Jenkinsfile"),
+	}
+	config.Properties.AddParameter(gojenkins.NewParameter(gojenkins.StringParameter, "TARGET", "staging", ""))
+	config.Properties.BuildDiscarder = gojenkins.NewBuildDiscarder(-1, 10)
+	job, err := client.CreateJobFromConfig(ctx, config, gojenkins.CreateJobOptions{Name: "deploy"})
+	assert.Nil(t, err)
+	assert.Equal(t, "deploy", job.GetName())
+	stored, _ := server.Job("deploy")
+	assert.Equal(t, jenkinstest.PipelineClass, stored.Class)
+	assert.Equal(t, "TARGET", stored.Parameters[0].Name)
+
+	typed, err := job.GetTypedConfig(ctx)
+	assert.Nil(t, err)
+	pipeline := typed.(*gojenkins.PipelineJobConfig)
+	assert.Equal(t, "Jenkinsfile", pipeline.Definition.ScriptPath)
+	pipeline.Description = "deploys the app & the docs"
+	pipeline.Properties.AddParameter(gojenkins.NewChoiceParameter("REGION", "", "eu", "us"))
+	assert.Nil(t, job.UpdateTypedConfig(ctx, pipeline))
+
+	stored, _ = server.Job("deploy")
+	assert.Equal(t, "deploys the app & the docs", stored.Description)
+	assert.Equal(t, 2, len(stored.Parameters))
+	assert.True(t, strings.Contains(stored.Config, "<string>us</string>"))
+}
@@ -0,0 +1,128 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiterRate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := gojenkins.NewClient(server.URL,
+		gojenkins.WithHTTPClient(server.Client()),
+		gojenkins.WithLimits(gojenkins.Limit{Rate: 20, Burst: 2}, gojenkins.Limit{}))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		_, err := client.Poll(ctx)
+		assert.Nil(t, err)
+	}
+	// Two requests fit in the burst, the other four wait 50ms each.
+	assert.True(t, time.Since(start) >= 180*time.Millisecond)
+
+	stats := client.Requester.LimiterStats()
+	assert.Equal(t, int64(6), stats.Read.Requests)
+	assert.Equal(t, int64(4), stats.Read.Delayed)
+	assert.True(t, stats.Read.MaxWait > 0 && stats.Read.MaxWait <= 50*time.Millisecond)
+	assert.Equal(t, gojenkins.LimitStats{}, stats.Write)
+}
+
+func TestLimiterMaxInFlight(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := gojenkins.NewClient(server.URL,
+		gojenkins.WithHTTPClient(server.Client()),
+		gojenkins.WithLimits(gojenkins.Limit{MaxInFlight: 2}, gojenkins.Limit{MaxInFlight: 1}))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Poll(ctx)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), maxInFlight)
+	stats := client.Requester.LimiterStats()
+	assert.Equal(t, int64(8), stats.Read.Requests)
+	assert.Equal(t, 0, stats.Read.InFlight)
+	assert.True(t, stats.Read.Delayed > 0)
+}
+
+func TestLimiterRateAndMaxInFlight(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := gojenkins.NewClient(server.URL,
+		gojenkins.WithHTTPClient(server.Client()),
+		gojenkins.WithLimits(gojenkins.Limit{Rate: 50, Burst: 2, MaxInFlight: 1}, gojenkins.Limit{}))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		_, err := client.Poll(ctx)
+		assert.Nil(t, err)
+	}
+	// Sequential requests never wait for a slot, only the three past the burst wait for a token.
+	stats := client.Requester.LimiterStats().Read
+	assert.Equal(t, int64(5), stats.Requests)
+	assert.Equal(t, int64(3), stats.Delayed)
+	assert.Equal(t, 0, stats.InFlight)
+}
+
+func TestLimiterCanceled(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	client, err := gojenkins.NewClient(server.URL,
+		gojenkins.WithHTTPClient(server.Client()),
+		gojenkins.WithLimits(gojenkins.Limit{MaxInFlight: 1}, gojenkins.Limit{}))
+	assert.Nil(t, err)
+
+	go client.Poll(context.Background())
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.Poll(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, int64(1), client.Requester.LimiterStats().Read.Canceled)
+}
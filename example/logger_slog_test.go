@@ -0,0 +1,29 @@
+//go:build go1.21
+// +build go1.21
+
+package example
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := gojenkins.NewSlogLogger(slog.New(handler))
+	logger.Debug("jenkins request", "method", "GET", "endpoint", "/job/app", "status", 200)
+	assert.Equal(t, "level=DEBUG msg=\"jenkins request\" method=GET endpoint=/job/app status=200\n", buf.String())
+}
@@ -0,0 +1,122 @@
+package example
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type logEntry struct {
+	level  string
+	msg    string
+	fields map[string]interface{}
+}
+
+// memoryLogger keeps every message in memory.
+type memoryLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (m *memoryLogger) log(level string, msg string, keysAndValues []interface{}) {
+	fields := make(map[string]interface{})
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fields[keysAndValues[i].(string)] = keysAndValues[i+1]
+	}
+	m.mu.Lock()
+	m.entries = append(m.entries, logEntry{level: level, msg: msg, fields: fields})
+	m.mu.Unlock()
+}
+
+func (m *memoryLogger) Debug(msg string, kv ...interface{}) { m.log("debug", msg, kv) }
+func (m *memoryLogger) Info(msg string, kv ...interface{})  { m.log("info", msg, kv) }
+func (m *memoryLogger) Warn(msg string, kv ...interface{})  { m.log("warn", msg, kv) }
+func (m *memoryLogger) Error(msg string, kv ...interface{}) { m.log("error", msg, kv) }
+
+func (m *memoryLogger) find(msg string) []logEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var found []logEntry
+	for _, e := range m.entries {
+		if e.msg == msg {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+func TestLoggerFields(t *testing.T) {
+	server, _ := flakyServer("/api/json", 1, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+
+	logger := &memoryLogger{}
+	policy := &gojenkins.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, RetryStatusCodes: []int{http.StatusServiceUnavailable}}
+	client, err := gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(server.Client()), gojenkins.WithRetry(policy), gojenkins.WithLogger(logger))
+	assert.Nil(t, err)
+
+	_, err = client.Poll(context.Background())
+	assert.Nil(t, err)
+
+	retries := logger.find("retrying request")
+	assert.Equal(t, 1, len(retries))
+	assert.Equal(t, "warn", retries[0].level)
+	assert.Equal(t, "GET", retries[0].fields["method"])
+	assert.Equal(t, http.StatusServiceUnavailable, retries[0].fields["status"])
+
+	requests := logger.find("jenkins request")
+	assert.Equal(t, 1, len(requests))
+	assert.Equal(t, http.StatusOK, requests[0].fields["status"])
+}
+
+func TestLoggerDefaultsToNop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/job1/api/json":
+			w.Write([]byte(`{"name":"job1","inQueue":true}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	// Init is never called, logging must not panic.
+	ctx := context.Background()
+	job, err := gojenkins.CreateJenkins(server.Client(), server.URL).GetJob(ctx, "job1")
+	assert.Nil(t, err)
+	number, err := job.InvokeSimple(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), number)
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := gojenkins.NewStdLogger(log.New(&buf, "", 0))
+	logger.Warn("job is already queued, not invoking it", "job", "app", "build", 3)
+	assert.Equal(t, "WARN job is already queued, not invoking it job=app build=3", strings.TrimSpace(buf.String()))
+
+	// Debug messages are left out unless asked for.
+	buf.Reset()
+	logger.Debug("jenkins request", "method", "GET")
+	logger.Info("retrying request", "attempt", 1)
+	assert.Equal(t, "INFO retrying request attempt=1\n", buf.String())
+
+	buf.Reset()
+	logger = gojenkins.NewStdLoggerLevel(log.New(&buf, "", 0), gojenkins.LogDebug)
+	logger.Debug("jenkins request", "method", "GET")
+	assert.Equal(t, "DEBUG jenkins request method=GET\n", buf.String())
+	buf.Reset()
+	logger = gojenkins.NewStdLoggerLevel(log.New(&buf, "", 0), gojenkins.LogError)
+	logger.Warn("slow")
+	assert.Equal(t, "", buf.String())
+}
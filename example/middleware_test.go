@@ -0,0 +1,123 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type observation struct {
+	method   string
+	endpoint string
+	status   int
+	err      error
+}
+
+type memoryCollector struct {
+	mu           sync.Mutex
+	observations []observation
+}
+
+func (c *memoryCollector) ObserveRequest(method string, endpoint string, status int, latency time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observations = append(c.observations, observation{method, endpoint, status, err})
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var headers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("X-Tenant"))
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var calls []string
+	trace := func(name string) gojenkins.Middleware {
+		return func(next gojenkins.Handler) gojenkins.Handler {
+			return func(ctx context.Context, ar *gojenkins.APIRequest) (*http.Response, error) {
+				calls = append(calls, name+" before")
+				ar.SetHeader("X-Tenant", name)
+				response, err := next(ctx, ar)
+				calls = append(calls, name+" after")
+				return response, err
+			}
+		}
+	}
+
+	client, err := gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(server.Client()), gojenkins.WithMiddleware(trace("outer")))
+	assert.Nil(t, err)
+	client.Requester.Use(trace("inner"))
+	_, err = client.Poll(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, calls)
+	assert.Equal(t, []string{"inner"}, headers)
+}
+
+func TestMiddlewareRequestID(t *testing.T) {
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get(gojenkins.RequestIDHeader))
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(server.Client()), gojenkins.WithMiddleware(gojenkins.RequestID()))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	_, err = client.Poll(ctx)
+	assert.Nil(t, err)
+	_, err = client.Poll(gojenkins.ContextWithRequestID(ctx, "req-42"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(ids))
+	assert.Len(t, ids[0], 32)
+	assert.Equal(t, "req-42", ids[1])
+}
+
+func TestMiddlewareMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/job/missing/api/json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	collector := &memoryCollector{}
+	client, err := gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(server.Client()), gojenkins.WithMiddleware(gojenkins.Metrics(collector)))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	_, err = client.GetJob(ctx, "job1")
+	assert.Nil(t, err)
+	_, err = client.GetJob(ctx, "missing")
+	assert.True(t, errors.Is(err, gojenkins.ErrNotFound))
+
+	assert.Equal(t, []observation{
+		{"GET", "/job/job1/", http.StatusOK, nil},
+		{"GET", "/job/missing/", http.StatusNotFound, nil},
+	}, collector.observations)
+}
+
+func TestMiddlewareReadOnly(t *testing.T) {
+	recorder := &authRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	client, err := gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(server.Client()), gojenkins.WithMiddleware(gojenkins.ReadOnly()))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	_, err = client.GetJob(ctx, "job1")
+	assert.Nil(t, err)
+	_, err = client.GetJobObj(ctx, "job1").Disable(ctx)
+	assert.True(t, errors.Is(err, gojenkins.ErrReadOnly))
+	assert.Empty(t, recorder.get("/job/job1/disable"))
+}
@@ -1,13 +1,17 @@
 package example
 
 import (
-	"fmt"
-	"github.com/sirupsen/logrus"
-	"os"
 	"testing"
+
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestCreateNode(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
+
 	node, err := jc.CreateNode(
 		jc.Context,
 		"NODE_NAME",
@@ -16,13 +20,23 @@ func TestCreateNode(t *testing.T) {
 		"REMOTEFS",
 		"LABEL",
 	)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-	logrus.Info(node)
+	assert.Nil(t, err)
+	assert.Equal(t, "NODE_NAME", node.GetName())
+	stored, ok := server.Node("NODE_NAME")
+	assert.True(t, ok)
+	assert.Equal(t, 10, stored.NumExecutors)
+	assert.Equal(t, "LABEL", stored.Label)
 }
 
 func TestDeleteNode(t *testing.T) {
-	_, _ = jc.DeleteNode(jc.Context, "nodeName")
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
+	assert.Nil(t, server.AddNode(jenkinstest.Node{Name: "nodeName", NumExecutors: 1}))
+
+	deleted, err := jc.DeleteNode(jc.Context, "nodeName")
+	assert.Nil(t, err)
+	assert.True(t, deleted)
+	_, ok := server.Node("nodeName")
+	assert.False(t, ok)
 }
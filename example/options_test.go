@@ -0,0 +1,91 @@
+package example
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClient(t *testing.T) {
+	recorder := &authRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	ctx := context.Background()
+	client, err := gojenkins.NewClient(server.URL+"/",
+		gojenkins.WithHTTPClient(server.Client()),
+		gojenkins.WithBearerToken("t"),
+		gojenkins.WithRetry(gojenkins.DefaultRetryPolicy()),
+		gojenkins.WithoutCrumbs())
+	assert.Nil(t, err)
+	assert.Equal(t, server.URL, client.Server)
+
+	_, err = client.GetJobObj(ctx, "job1").Enable(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Bearer t"}, recorder.get("/job/job1/enable"))
+	assert.Empty(t, recorder.get("/crumbIssuer/api/json"))
+}
+
+func TestNewClientErrors(t *testing.T) {
+	_, err := gojenkins.NewClient("jenkins.example.com")
+	assert.NotNil(t, err)
+
+	_, err = gojenkins.NewClient("https://jenkins.example.com", gojenkins.WithTLS(gojenkins.TLSOptions{CACert: []byte("x")}))
+	assert.EqualError(t, err, "gojenkins: no valid certificate found in CACert")
+}
+
+func TestCreateWithOptions(t *testing.T) {
+	var mu sync.Mutex
+	forms := make(map[string]map[string][]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		forms[r.URL.Path] = r.Form
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client, err := gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(server.Client()), gojenkins.WithoutCrumbs())
+	assert.Nil(t, err)
+
+	job, err := client.CreateJobWithOptions(ctx, "<project/>", gojenkins.CreateJobOptions{Name: "app", Folders: []string{"team"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "/job/team/job/app", job.Base)
+	assert.Equal(t, []string{"app"}, forms["/job/team/createItem"]["name"])
+
+	_, err = client.CreateJobWithOptions(ctx, "<project/>", gojenkins.CreateJobOptions{})
+	assert.NotNil(t, err)
+
+	_, err = client.CreateNodeWithOptions(ctx, gojenkins.CreateNodeOptions{
+		Name:         "agent",
+		NumExecutors: 2,
+		Launcher:     gojenkins.SSHLauncher{Host: "agent.example.com", Port: 22, CredentialsID: "ssh", LaunchTimeoutSeconds: 60},
+	})
+	assert.Nil(t, err)
+	var node struct {
+		NumExecutors int               `json:"numExecutors"`
+		Launcher     map[string]string `json:"launcher"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(forms["/computer/doCreateItem"]["json"][0]), &node))
+	assert.Equal(t, 2, node.NumExecutors)
+	assert.Equal(t, "agent.example.com", node.Launcher["host"])
+	assert.Equal(t, "22", node.Launcher["port"])
+	assert.Equal(t, "", node.Launcher["maxNumRetries"])
+	assert.Equal(t, "60", node.Launcher["launchTimeoutSeconds"])
+	_, typo := node.Launcher["lanuchTimeoutSeconds"]
+	assert.False(t, typo)
+
+	// The deprecated entry points no longer panic on arguments of the wrong type.
+	_, err = client.CreateJob(ctx, "<project/>", 42)
+	assert.NotNil(t, err)
+	_, err = client.CreateNode(ctx, "agent2", 1, "", "/home/jenkins", "", []string{"SSHLauncher"})
+	assert.Nil(t, err)
+}
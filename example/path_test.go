@@ -0,0 +1,77 @@
+package example
+
+import (
+	"context"
+	"testing"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobPath(t *testing.T) {
+	assert.Equal(t, "/job/team/job/service/job/deploy", gojenkins.JobPath("team/service/deploy"))
+	assert.Equal(t, "/job/team/job/deploy", gojenkins.JobPath("team", "deploy"))
+	assert.Equal(t, "/job/my%20app%20%231/job/50%25%3F/job/d%C3%A9ploiement", gojenkins.JobPath("my app #1/50%?/déploiement"))
+}
+
+func TestEscapedNames(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+	assert.Nil(t, server.AddFolder("team a"))
+	assert.Nil(t, server.AddJob("team a/deploy #1?", jenkinstest.FreeStyleConfig))
+	assert.Nil(t, server.AddJob("deploy", jenkinstest.FreeStyleConfig))
+
+	job, err := client.GetJob(ctx, "team a/deploy #1?")
+	assert.Nil(t, err)
+	assert.Equal(t, "team a/deploy #1?", job.Raw.FullName)
+	job, err = client.GetJob(ctx, "deploy #1?", "team a")
+	assert.Nil(t, err)
+	assert.Equal(t, "team a/deploy #1?", job.Raw.FullName)
+
+	_, err = client.CreateJobWithOptions(ctx, jenkinstest.FreeStyleConfig, gojenkins.CreateJobOptions{Name: "naïve job", Folders: []string{"team a"}})
+	assert.Nil(t, err)
+	_, ok := server.Job("team a/naïve job")
+	assert.True(t, ok)
+
+	node, err := client.CreateNodeWithOptions(ctx, gojenkins.CreateNodeOptions{Name: "agent #2", NumExecutors: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "agent #2", node.GetName())
+}
+
+func TestParseURL(t *testing.T) {
+	client, err := gojenkins.NewClient("https://ci.example.com/jenkins/")
+	assert.Nil(t, err)
+
+	tests := []struct {
+		url string
+		ref gojenkins.Ref
+	}{
+		{"https://ci.example.com/jenkins/job/team/job/my%20app/", gojenkins.Ref{Kind: gojenkins.KindJob, FullName: "team/my app"}},
+		{"https://proxy.local/jenkins/job/team/job/app/42/console", gojenkins.Ref{Kind: gojenkins.KindBuild, FullName: "team/app", Number: 42}},
+		{"/jenkins/job/app/lastBuild/", gojenkins.Ref{Kind: gojenkins.KindBuild, FullName: "app", Permalink: "lastBuild"}},
+		{"https://ci.example.com/jenkins/job/app/configure", gojenkins.Ref{Kind: gojenkins.KindJob, FullName: "app"}},
+		{"https://ci.example.com/jenkins/computer/agent%20%232/", gojenkins.Ref{Kind: gojenkins.KindNode, Name: "agent #2"}},
+		{"https://ci.example.com/jenkins/view/all/view/nested/", gojenkins.Ref{Kind: gojenkins.KindView, Name: "nested", ParentViews: "all"}},
+		{"http://x/jenkins/view/a/view/b%20c/view/d/", gojenkins.Ref{Kind: gojenkins.KindView, Name: "d", ParentViews: "a/b c"}},
+		{"https://ci.example.com/jenkins/job/team/view/ops/", gojenkins.Ref{Kind: gojenkins.KindView, FullName: "team", Name: "ops"}},
+		{"https://ci.example.com/jenkins/label/linux/", gojenkins.Ref{Kind: gojenkins.KindLabel, Name: "linux"}},
+	}
+	for _, test := range tests {
+		ref, err := client.ParseURL(test.url)
+		assert.Nil(t, err, test.url)
+		assert.Equal(t, test.ref, ref, test.url)
+	}
+
+	ref, _ := client.ParseURL("https://ci.example.com/jenkins/job/team/job/my%20app/7/")
+	assert.Equal(t, "/job/team/job/my%20app/7", ref.Path())
+	ref, _ = client.ParseURL("http://x/jenkins/job/team/view/a/view/b%20c/")
+	assert.Equal(t, "/job/team/view/a/view/b%20c", ref.Path())
+
+	_, err = client.ParseURL("https://ci.example.com/job/app/")
+	assert.NotNil(t, err)
+	_, err = client.ParseURL("https://ci.example.com/jenkins/manage/")
+	assert.NotNil(t, err)
+}
@@ -1,25 +1,31 @@
 package example
 
 import (
-	"fmt"
-	"github.com/reaperhero/client-jenkins-go/utils"
 	"testing"
+
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/reaperhero/client-jenkins-go/utils"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestShowBuildQueue(t *testing.T) {
-	queue, _ := jc.GetQueue(jc.Context)
-	totalTasks := 0
-	for i, item := range queue.Raw.Items {
-		fmt.Printf("Name: %s\n", item.Task.Name)
-		fmt.Printf("ID: %d\n", item.ID)
-		utils.ShowStatus(item.Task.Color)
-		fmt.Printf("Pending: %v\n", item.Pending)
-		fmt.Printf("Stuck: %v\n", item.Stuck)
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
+	assert.Nil(t, server.AddJob("a", jenkinstest.FreeStyleConfig))
+	assert.Nil(t, server.AddJob("b", jenkinstest.FreeStyleConfig))
+	for _, name := range []string{"a", "b"} {
+		_, err := jc.BuildJob(jc.Context, name, nil)
+		assert.Nil(t, err)
+	}
 
-		fmt.Printf("Why: %s\n", item.Why)
-		fmt.Printf("URL: %s\n", item.Task.URL)
-		fmt.Printf("\n")
-		totalTasks = i + 1
+	queue, err := jc.GetQueue(jc.Context)
+	assert.Nil(t, err)
+	var names []string
+	for _, item := range queue.Raw.Items {
+		names = append(names, item.Task.Name)
+		utils.ShowStatus(item.Task.Color)
+		assert.True(t, item.ID > 0)
 	}
-	fmt.Printf("Number of tasks in the build queue: %d\n", totalTasks)
+	assert.ElementsMatch(t, []string{"a", "b"}, names)
 }
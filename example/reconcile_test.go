@@ -0,0 +1,168 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeJobDir writes config.xml files below dir, keyed by the directory of the job.
+func writeJobDir(t *testing.T, dir string, configs map[string]string) {
+	for name, config := range configs {
+		jobDir := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(jobDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if config == "" {
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(jobDir, "config.xml"), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func planned(plan *gojenkins.JobPlan) []string {
+	var changes []string
+	for _, change := range plan.Changes {
+		changes = append(changes, string(change.Action)+" "+change.FullName)
+	}
+	return changes
+}
+
+func TestNormalizeConfig(t *testing.T) {
+	a, err := gojenkins.NormalizeConfig(`<?xml version='1.1' encoding='UTF-8'?>
+<project><!-- ours --><description>multi
+line</description><scm plugin="git" class="hudson.scm.NullSCM"></scm><builders>
+</builders></project>`)
+	assert.Nil(t, err)
+	assert.Equal(t, `<project>
+  <description>multi
+line</description>
+  <scm class="hudson.scm.NullSCM" plugin="git"/>
+  <builders/>
+</project>
+`, a)
+	b, err := gojenkins.NormalizeConfig(`<project>
+    <description>multi
+line</description>
+    <scm class="hudson.scm.NullSCM" plugin="git"/>
+    <builders/>
+</project>`)
+	assert.Nil(t, err)
+	assert.Equal(t, a, b)
+
+	_, err = gojenkins.NormalizeConfig("<project>")
+	assert.NotNil(t, err)
+}
+
+func TestReconcileJobs(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+	assert.Nil(t, server.AddFolder("team"))
+	assert.Nil(t, server.AddJob("team/deploy", jenkinstest.FreeStyleConfig))
+	assert.Nil(t, server.AddJob("team/old", jenkinstest.FreeStyleConfig))
+	assert.Nil(t, server.AddJob("same", jenkinstest.PipelineConfig))
+	assert.Nil(t, server.AddJob("stale", jenkinstest.FreeStyleConfig))
+	assert.Nil(t, server.AddFolder("stale folder"))
+	assert.Nil(t, server.AddJob("stale folder/job", jenkinstest.FreeStyleConfig))
+
+	dir := t.TempDir()
+	writeJobDir(t, dir, map[string]string{
+		"team":             "",
+		"team/deploy":      strings.Replace(jenkinstest.FreeStyleConfig, "<description></description>", "<description>deploys</description>", 1),
+		"team/build":       jenkinstest.PipelineConfig,
+		"new":              jenkinstest.FolderConfig,
+		"new/job":          jenkinstest.FreeStyleConfig,
+		"same":             strings.Replace(strings.Replace(jenkinstest.PipelineConfig, "\n  ", "\n\t", -1), "?>", "?>\n<!-- reformatted -->", 1),
+		".git/description": jenkinstest.FreeStyleConfig,
+	})
+
+	plan, err := client.PlanJobs(ctx, dir, gojenkins.ReconcileOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"create new", "create new/job", "create team/build", "update team/deploy"}, planned(plan))
+	assert.Contains(t, plan.Changes[3].Diff, "-  <description/>\n+  <description>deploys</description>\n")
+	assert.Contains(t, plan.String(), "~ team/deploy\n--- a/team/deploy/config.xml\n")
+
+	plan, report, err := client.ReconcileJobs(ctx, dir, gojenkins.ReconcileOptions{Prune: true, DryRun: true})
+	assert.Nil(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, []string{"create new", "create new/job", "delete stale", "delete stale folder", "create team/build", "update team/deploy", "delete team/old"}, planned(plan))
+	assert.Equal(t, 7, len(server.Jobs()))
+
+	plan, report, err = client.ReconcileJobs(ctx, dir, gojenkins.ReconcileOptions{Prune: true, Concurrency: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, 7, len(report.Results))
+	assert.Equal(t, 0, len(report.Failed()))
+	assert.Equal(t, []string{"new", "new/job", "same", "team", "team/build", "team/deploy"}, server.Jobs())
+	deploy, _ := server.Job("team/deploy")
+	assert.Equal(t, "deploys", deploy.Description)
+	folder, _ := server.Job("new")
+	assert.True(t, folder.IsFolder())
+
+	plan, err = client.PlanJobs(ctx, dir, gojenkins.ReconcileOptions{Prune: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(plan.Changes))
+
+	// The plan of a cached client sees the edits of other clients.
+	cached := newFakeClient(t, server, gojenkins.WithCache(gojenkins.CacheConfig{TTL: time.Hour}))
+	plan, err = cached.PlanJobs(ctx, dir, gojenkins.ReconcileOptions{Prune: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(plan.Changes))
+	job, err := client.GetJob(ctx, "team/deploy")
+	assert.Nil(t, err)
+	_, err = job.PatchConfig(ctx, gojenkins.PatchConfigOptions{}, gojenkins.SetText("description", "edited"))
+	assert.Nil(t, err)
+	plan, err = cached.PlanJobs(ctx, dir, gojenkins.ReconcileOptions{Prune: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"update team/deploy"}, planned(plan))
+}
+
+func TestReconcileJobsFailures(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server, gojenkins.WithMiddleware(func(next gojenkins.Handler) gojenkins.Handler {
+		return func(ctx context.Context, ar *gojenkins.APIRequest) (*http.Response, error) {
+			if ar.Method == http.MethodPost && ar.Query.Get("name") == "lab" {
+				return nil, errors.New("lab is off limits")
+			}
+			return next(ctx, ar)
+		}
+	}))
+	assert.Nil(t, server.AddJob("app", jenkinstest.FreeStyleConfig))
+
+	dir := t.TempDir()
+	writeJobDir(t, dir, map[string]string{
+		"app":       jenkinstest.PipelineConfig,
+		"lab":       "",
+		"lab/probe": jenkinstest.FreeStyleConfig,
+		"ok":        jenkinstest.FreeStyleConfig,
+	})
+	plan, report, err := client.ReconcileJobs(ctx, dir, gojenkins.ReconcileOptions{Folder: "/"})
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"update app", "create lab", "create lab/probe", "create ok"}, planned(plan))
+	failed := report.Failed()
+	assert.Equal(t, 3, len(failed))
+	assert.Equal(t, "app", failed[0].FullName)
+	assert.Contains(t, failed[1].Err.Error(), "lab is off limits")
+	assert.Contains(t, failed[2].Err.Error(), "folder lab was not created")
+	_, ok := server.Job("ok")
+	assert.True(t, ok)
+
+	writeJobDir(t, dir, map[string]string{"app": "<project>"})
+	_, err = client.PlanJobs(ctx, dir, gojenkins.ReconcileOptions{})
+	assert.NotNil(t, err)
+}
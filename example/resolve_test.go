@@ -0,0 +1,142 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/stretchr/testify/assert"
+)
+
+const multibranchConfig = `<org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject plugin="workflow-multibranch"/>`
+
+func TestResolve(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+	assert.Nil(t, server.AddFolder("a"))
+	assert.Nil(t, server.AddJob("a/b", jenkinstest.FreeStyleConfig))
+	assert.Nil(t, server.AddJob("a/mb", multibranchConfig))
+	assert.Nil(t, server.AddJob("a/mb/feature%2Flogin", jenkinstest.PipelineConfig))
+	for i := 0; i < 3; i++ {
+		_, err := server.AddBuild("a/b", jenkinstest.ResultSuccess, "")
+		assert.Nil(t, err)
+	}
+	_, err := server.AddBuild("a/b", jenkinstest.ResultFailure, "")
+	assert.Nil(t, err)
+	_, err = server.AddBuild("a/mb/feature%2Flogin", jenkinstest.ResultSuccess, "")
+	assert.Nil(t, err)
+
+	r, err := client.Resolve(ctx, server.URL+"/job/a/job/b/2/console")
+	assert.Nil(t, err)
+	build, ok := r.(*gojenkins.Build)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), build.GetBuildNumber())
+	assert.Equal(t, "/job/a/job/b/2", build.BasePath())
+
+	r, err = client.Resolve(ctx, server.URL+"/job/a/job/b/lastSuccessfulBuild/")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), r.(*gojenkins.Build).GetBuildNumber())
+
+	r, err = client.Resolve(ctx, server.URL+"/job/a/job/b/")
+	assert.Nil(t, err)
+	assert.Equal(t, "a/b", r.(*gojenkins.Job).Raw.FullName)
+
+	r, err = client.Resolve(ctx, server.URL+"/job/a/")
+	assert.Nil(t, err)
+	assert.Equal(t, gojenkins.KindFolder, r.Kind())
+	r, err = client.Resolve(ctx, server.URL+"/job/a/job/mb/")
+	assert.Nil(t, err)
+	assert.Equal(t, gojenkins.KindFolder, r.Kind())
+
+	r, err = client.Resolve(ctx, server.URL+"/job/a/job/mb/job/feature%252Flogin/1/")
+	assert.Nil(t, err)
+	assert.Equal(t, "/job/a/job/mb/job/feature%252Flogin/1", r.BasePath())
+
+	r, err = client.Resolve(ctx, server.URL+"/computer/(built-in)/")
+	assert.Nil(t, err)
+	assert.Equal(t, gojenkins.KindNode, r.Kind())
+	r, err = client.Resolve(ctx, server.URL+"/view/all/")
+	assert.Nil(t, err)
+	assert.Equal(t, gojenkins.KindView, r.Kind())
+
+	_, err = client.Resolve(ctx, server.URL+"/job/a/job/missing/")
+	assert.True(t, errors.Is(err, gojenkins.ErrNotFound))
+	r, err = client.Resolve(ctx, server.URL+"/job/a/job/mb/job/feature%252Flogin/lastFailedBuild/")
+	assert.True(t, errors.Is(err, gojenkins.ErrNotFound))
+	assert.True(t, r == nil)
+	r, err = client.Resolve(ctx, server.URL+"/computer/missing/")
+	assert.NotNil(t, err)
+	assert.True(t, r == nil)
+
+	for _, foreign := range []string{
+		strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/job/a/job/b/",
+		strings.Replace(server.URL, "http:", "https:", 1) + "/job/a/job/b/",
+		"http://ci.example.com/job/a/job/b/",
+	} {
+		r, err = client.Resolve(ctx, foreign)
+		assert.NotNil(t, err, foreign)
+		assert.Contains(t, err.Error(), "is not on the Jenkins server")
+		assert.True(t, r == nil)
+	}
+}
+
+func TestResolveNestedView(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		fmt.Fprint(w, `{"_class":"hudson.model.ListView","name":"b c"}`)
+	}))
+	defer server.Close()
+	client, err := gojenkins.NewClient(server.URL + "/jenkins")
+	assert.Nil(t, err)
+
+	r, err := client.Resolve(context.Background(), server.URL+"/jenkins/view/a/view/b%20c/")
+	assert.Nil(t, err)
+	assert.Equal(t, gojenkins.KindView, r.Kind())
+	assert.Equal(t, "/view/a/view/b%20c", r.BasePath())
+	assert.Equal(t, []string{"/jenkins/view/a/view/b%20c/api/json"}, paths)
+}
+
+func TestParseMatrixURL(t *testing.T) {
+	client, err := gojenkins.NewClient("https://ci.example.com")
+	assert.Nil(t, err)
+	ref, err := client.ParseURL("https://ci.example.com/job/f/job/m/jdk=11,os=linux/12/")
+	assert.Nil(t, err)
+	assert.Equal(t, gojenkins.Ref{Kind: gojenkins.KindBuild, FullName: "f/m", Configuration: "jdk=11,os=linux", Number: 12}, ref)
+
+	ref, err = client.ParseURL("https://ci.example.com/queue/item/17/")
+	assert.Nil(t, err)
+	assert.Equal(t, gojenkins.Ref{Kind: gojenkins.KindQueueItem, Number: 17}, ref)
+}
+
+func TestGetMatrixRuns(t *testing.T) {
+	var paths []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		if r.URL.Path == "/jenkins/job/f/job/m/5/api/json" {
+			fmt.Fprintf(w, `{"number":5,"runs":[{"number":5,"url":"%s/jenkins/job/f/job/m/os=linux/5/"},{"number":5,"url":"%s/jenkins/job/f/job/m/os=windows/5/"}]}`, server.URL, server.URL)
+			return
+		}
+		fmt.Fprint(w, `{"number":5}`)
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	client, err := gojenkins.NewClient(server.URL + "/jenkins")
+	assert.Nil(t, err)
+
+	build := &gojenkins.Build{Jenkins: client, Raw: new(gojenkins.BuildResponse), Base: "/job/f/job/m/5"}
+	runs, err := build.GetMatrixRuns(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(runs))
+	assert.Equal(t, "/job/f/job/m/os=linux/5", runs[0].BasePath())
+	assert.Contains(t, paths, "/jenkins/job/f/job/m/os=windows/5/api/json")
+}
@@ -0,0 +1,96 @@
+package example
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceInterface(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+	assert.Nil(t, server.AddJob("app", jenkinstest.FreeStyleConfig))
+
+	job, err := client.GetJob(ctx, "app")
+	assert.Nil(t, err)
+	node, err := client.GetNode(ctx, "(built-in)")
+	assert.Nil(t, err)
+	queue, err := client.GetQueue(ctx)
+	assert.Nil(t, err)
+
+	resources := []gojenkins.Resource{job, node, queue}
+	kinds := []gojenkins.ResourceKind{gojenkins.KindJob, gojenkins.KindNode, gojenkins.KindQueue}
+	for i, r := range resources {
+		assert.Equal(t, kinds[i], r.Kind())
+		assert.Equal(t, server.URL+r.BasePath(), r.AbsoluteURL())
+		assert.Nil(t, gojenkins.Refresh(ctx, r))
+	}
+	assert.Equal(t, "/job/app", job.BasePath())
+
+	snapshot, err := gojenkins.Snapshot(job)
+	assert.Nil(t, err)
+	assert.Equal(t, gojenkins.KindJob, snapshot.Kind)
+	var raw map[string]interface{}
+	assert.Nil(t, json.Unmarshal(snapshot.Raw, &raw))
+	assert.Equal(t, "app", raw["name"])
+}
+
+func TestResourceExists(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	client := newFakeClient(t, server)
+	assert.Nil(t, server.AddJob("app", jenkinstest.FreeStyleConfig))
+
+	exists, err := gojenkins.Exists(ctx, &gojenkins.Job{Jenkins: client, Raw: new(gojenkins.JobResponse), Base: "/job/app"})
+	assert.Nil(t, err)
+	assert.True(t, exists)
+	exists, err = gojenkins.Exists(ctx, &gojenkins.Job{Jenkins: client, Raw: new(gojenkins.JobResponse), Base: "/job/missing"})
+	assert.Nil(t, err)
+	assert.False(t, exists)
+}
+
+func TestResourceWaitUntil(t *testing.T) {
+	server := jenkinstest.NewServer(jenkinstest.WithAutoStart())
+	defer server.Close()
+	ctx := context.Background()
+	// The TTL cache would answer every poll with the running build.
+	client := newFakeClient(t, server, gojenkins.WithCache(gojenkins.CacheConfig{TTL: time.Hour}))
+	assert.Nil(t, server.AddJob("app", jenkinstest.FreeStyleConfig))
+
+	job, err := client.GetJob(ctx, "app")
+	assert.Nil(t, err)
+	queueID, err := job.InvokeSimple(ctx, nil)
+	assert.Nil(t, err)
+	build, err := client.GetBuildFromQueueID(ctx, job, queueID)
+	assert.Nil(t, err)
+	assert.True(t, build.Raw.Building)
+
+	number := build.GetBuildNumber()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		server.FinishBuild("app", number, jenkinstest.ResultSuccess)
+	}()
+	waitCtx, cancelWait := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelWait()
+	err = gojenkins.WaitUntil(waitCtx, build, 5*time.Millisecond, func(gojenkins.Resource) bool { return !build.Raw.Building })
+	assert.Nil(t, err)
+	assert.Equal(t, "SUCCESS", build.GetResult())
+	// The deprecated depth argument of Build.Poll is still accepted.
+	status, err := build.Poll(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = gojenkins.WaitUntil(ctx, job, 5*time.Millisecond, func(gojenkins.Resource) bool { return false })
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,138 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyServer fails the first `failures` requests to path with the given handler, then answers 200.
+func flakyServer(path string, failures int32, fail http.HandlerFunc) (*httptest.Server, *int32) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		if atomic.AddInt32(&attempts, 1) <= failures {
+			fail(w, r)
+			return
+		}
+		w.Write([]byte(`{"name":"job1"}`))
+	}))
+	return server, &attempts
+}
+
+func unavailable(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+func testRetryPolicy() *gojenkins.RetryPolicy {
+	policy := gojenkins.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	return policy
+}
+
+func TestRetryUnavailable(t *testing.T) {
+	server, attempts := flakyServer("/job/job1/api/json", 2, unavailable)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	client.Requester.Retry = testRetryPolicy()
+	job, err := client.GetJob(ctx, "job1")
+	assert.Nil(t, err)
+	assert.Equal(t, "job1", job.GetName())
+	assert.Equal(t, int32(3), atomic.LoadInt32(attempts))
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server, attempts := flakyServer("/job/job1/api/json", 10, unavailable)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	client.Requester.Retry = testRetryPolicy()
+	_, err := client.GetJob(ctx, "job1")
+	var apiErr *gojenkins.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, int32(4), atomic.LoadInt32(attempts))
+}
+
+func TestRetryConnectionReset(t *testing.T) {
+	server, attempts := flakyServer("/job/job1/api/json", 1, func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	client.Requester.Retry = testRetryPolicy()
+	_, err := client.GetJob(ctx, "job1")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(attempts))
+}
+
+func TestRetryPostRequiresOptIn(t *testing.T) {
+	server, attempts := flakyServer("/job/job1/enable", 1, unavailable)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	client.Requester.Retry = testRetryPolicy()
+	_, err := client.GetJobObj(ctx, "job1").Enable(ctx)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(attempts))
+
+	client.Requester.Retry.RetryNonIdempotent = true
+	atomic.StoreInt32(attempts, 0)
+	ok, err := client.GetJobObj(ctx, "job1").Enable(ctx)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int32(2), atomic.LoadInt32(attempts))
+}
+
+func TestRetryAfter(t *testing.T) {
+	server, attempts := flakyServer("/job/job1/api/json", 1, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	client.Requester.Retry = testRetryPolicy()
+	start := time.Now()
+	_, err := client.GetJob(ctx, "job1")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(attempts))
+	assert.True(t, time.Since(start) >= time.Second)
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	server, attempts := flakyServer("/job/job1/api/json", 10, unavailable)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	client.Requester.Retry = testRetryPolicy()
+	client.Requester.Retry.InitialBackoff = time.Minute
+	client.Requester.Retry.MaxBackoff = time.Minute
+	start := time.Now()
+	_, err := client.GetJob(ctx, "job1")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, time.Since(start) < 10*time.Second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(attempts))
+}
@@ -0,0 +1,171 @@
+package example
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCA struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	serial  int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gojenkins test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), serial: 1}
+}
+
+// issue returns a PEM certificate and key signed by the CA.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca.serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func newTLSJenkins(t *testing.T, ca *testCA, config *tls.Config) *httptest.Server {
+	certPEM, keyPEM := ca.issue(t, "jenkins", x509.ExtKeyUsageServerAuth)
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Certificates = []tls.Certificate{certificate}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Jenkins", "2.401")
+		w.Write([]byte(`{"mode":"NORMAL"}`))
+	}))
+	server.TLS = config
+	server.StartTLS()
+	return server
+}
+
+func TestTLSCustomCA(t *testing.T) {
+	ca := newTestCA(t)
+	server := newTLSJenkins(t, ca, &tls.Config{})
+	defer server.Close()
+
+	ctx := context.Background()
+	_, err := gojenkins.CreateJenkins(nil, server.URL).Init(ctx)
+	assert.NotNil(t, err, "the generated CA is not trusted by default")
+
+	_, err = gojenkins.CreateJenkins(nil, server.URL, gojenkins.TLSOptions{CACert: ca.certPEM}).Init(ctx)
+	assert.Nil(t, err)
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	server := newTLSJenkins(t, ca, &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool})
+	defer server.Close()
+
+	ctx := context.Background()
+	_, err := gojenkins.CreateJenkins(nil, server.URL, gojenkins.TLSOptions{CACert: ca.certPEM}).Init(ctx)
+	assert.NotNil(t, err, "the server requires a client certificate")
+
+	certPEM, keyPEM := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	options := gojenkins.TLSOptions{CACert: ca.certPEM, ClientCert: certPEM, ClientKey: keyPEM}
+	jenkins, err := gojenkins.CreateJenkins(nil, server.URL, "admin", "admin", options).Init(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "2.401", jenkins.Version)
+}
+
+func TestTLSMinVersion(t *testing.T) {
+	ca := newTestCA(t)
+	server := newTLSJenkins(t, ca, &tls.Config{MaxVersion: tls.VersionTLS12})
+	defer server.Close()
+
+	ctx := context.Background()
+	options := gojenkins.TLSOptions{CACert: ca.certPEM, MinVersion: tls.VersionTLS13}
+	_, err := gojenkins.CreateJenkins(nil, server.URL, options).Init(ctx)
+	assert.NotNil(t, err)
+
+	options.MinVersion = tls.VersionTLS12
+	_, err = gojenkins.CreateJenkins(nil, server.URL, options).Init(ctx)
+	assert.Nil(t, err)
+}
+
+func TestTLSInsecureSkipVerify(t *testing.T) {
+	ca := newTestCA(t)
+	server := newTLSJenkins(t, ca, &tls.Config{})
+	defer server.Close()
+
+	ctx := context.Background()
+	_, err := gojenkins.CreateJenkins(nil, server.URL, gojenkins.TLSOptions{InsecureSkipVerify: true}).Init(ctx)
+	assert.Nil(t, err)
+}
+
+func TestTLSVerifiesByDefault(t *testing.T) {
+	config, err := (&gojenkins.Requester{}).TLSConfig()
+	assert.Nil(t, err)
+	assert.False(t, config.InsecureSkipVerify)
+
+	ca := newTestCA(t)
+	server := newTLSJenkins(t, ca, &tls.Config{})
+	defer server.Close()
+	requester := &gojenkins.Requester{Base: server.URL, Client: &http.Client{}}
+	assert.Nil(t, requester.ConfigureTLS(gojenkins.TLSOptions{}))
+	_, err = requester.GetJSON(context.Background(), "/", &map[string]interface{}{}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestTLSInvalidCA(t *testing.T) {
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(nil, "https://127.0.0.1:1", gojenkins.TLSOptions{CACert: []byte("not a certificate")})
+	_, err := client.Init(ctx)
+	assert.EqualError(t, err, "gojenkins: no valid certificate found in CACert")
+}
@@ -0,0 +1,188 @@
+package example
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTraceServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/crumbIssuer/api/json":
+			http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "session-cookie"})
+			w.Write([]byte(`{"crumb":"crumb-value","crumbRequestField":"Jenkins-Crumb"}`))
+		case "/job/token/api/json":
+			w.Write([]byte(`{"name":"token","apiToken":"token-secret"}`))
+		default:
+			w.Write([]byte(`{"name":"job1","description":"` + strings.Repeat("x", 100) + `"}`))
+		}
+	}))
+}
+
+func TestTraceRedactsSecrets(t *testing.T) {
+	server := newTraceServer()
+	defer server.Close()
+
+	var mu sync.Mutex
+	var events []*gojenkins.TraceEvent
+	var buf bytes.Buffer
+	trace := &gojenkins.TraceConfig{Writer: &buf, Func: func(e *gojenkins.TraceEvent) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}}
+	client, err := gojenkins.NewClient(server.URL,
+		gojenkins.WithHTTPClient(server.Client()),
+		gojenkins.WithBasicAuth("admin", "hunter2"),
+		gojenkins.WithTrace(trace))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	cm := &gojenkins.CredentialsManager{J: client}
+	err = cm.Add(ctx, "_", gojenkins.UsernameCredentials{ID: "db", Username: "app", Password: "s3cr3t-password"})
+	assert.Nil(t, err)
+	_, err = client.GetJobObj(ctx, "job1").InvokeSimple(ctx, map[string]string{"DB_PASSWORD": "param-secret", "BRANCH": "main"})
+	assert.NotNil(t, err, "the fake server sends no Location header")
+
+	output := buf.String()
+	for _, secret := range []string{"hunter2", "s3cr3t-password", "crumb-value", "session-cookie", "param-secret"} {
+		assert.NotContains(t, output, secret)
+	}
+	assert.Contains(t, output, "BRANCH=main")
+	assert.Contains(t, output, "<password>REDACTED</password>")
+	assert.Contains(t, output, "Jenkins-Crumb: REDACTED")
+
+	assert.NotEmpty(t, events)
+	first := events[0]
+	assert.Equal(t, "GET", first.Method)
+	assert.Equal(t, server.URL+"/crumbIssuer/api/json", first.URL)
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+	assert.Equal(t, []string{"REDACTED"}, first.RequestHeader["Authorization"])
+	assert.Equal(t, []string{"REDACTED"}, first.ResponseHeader["Set-Cookie"])
+}
+
+func TestTraceBodyLimit(t *testing.T) {
+	server := newTraceServer()
+	defer server.Close()
+
+	var event *gojenkins.TraceEvent
+	trace := &gojenkins.TraceConfig{MaxBodySize: 10, Func: func(e *gojenkins.TraceEvent) { event = e }}
+	client, err := gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(server.Client()), gojenkins.WithTrace(trace))
+	assert.Nil(t, err)
+
+	job, err := client.GetJob(context.Background(), "job1")
+	assert.Nil(t, err)
+	// The traced response is still decoded in full.
+	assert.Equal(t, 100, len(job.GetDescription()))
+	assert.Equal(t, `{"name":"j`, string(event.ResponseBody))
+	assert.True(t, event.ResponseTruncated)
+
+	// The secret is redacted before the body is cut in its middle.
+	trace.MaxBodySize = 32
+	_, err = client.GetJob(context.Background(), "token")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"name":"token","apiToken":"REDA`, string(event.ResponseBody))
+}
+
+func TestTraceStreamsLargeBodies(t *testing.T) {
+	released := make(chan struct{})
+	timedOut := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"job1","description":"` + strings.Repeat("x", 64<<10)))
+		w.(http.Flusher).Flush()
+		// The trace is recorded before the rest of the body is sent.
+		select {
+		case <-released:
+		case <-time.After(5 * time.Second):
+			timedOut = true
+		}
+		w.Write([]byte(strings.Repeat("y", 1<<20) + `"}`))
+	}))
+	defer server.Close()
+
+	var event *gojenkins.TraceEvent
+	trace := &gojenkins.TraceConfig{MaxBodySize: 10, Func: func(e *gojenkins.TraceEvent) {
+		event = e
+		close(released)
+	}}
+	client, err := gojenkins.NewClient(server.URL, gojenkins.WithHTTPClient(server.Client()), gojenkins.WithTrace(trace))
+	assert.Nil(t, err)
+
+	job, err := client.GetJob(context.Background(), "job1")
+	assert.Nil(t, err)
+	assert.False(t, timedOut)
+	assert.Equal(t, 64<<10+1<<20, len(job.GetDescription()))
+	assert.Equal(t, `{"name":"j`, string(event.ResponseBody))
+	assert.True(t, event.ResponseTruncated)
+}
+
+// roundTripFunc answers requests without a server.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// brokenBody fails once after data, like a connection reset, and then ends.
+type brokenBody struct {
+	data   *strings.Reader
+	failed bool
+}
+
+func (b *brokenBody) Read(p []byte) (int, error) {
+	if b.data.Len() > 0 {
+		return b.data.Read(p)
+	}
+	if !b.failed {
+		b.failed = true
+		return 0, errors.New("connection reset by peer")
+	}
+	return 0, io.EOF
+}
+
+func (b *brokenBody) Close() error {
+	return nil
+}
+
+func TestTraceKeepsReadErrors(t *testing.T) {
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/xml"}},
+			Body:       &brokenBody{data: strings.NewReader("<project/>")},
+			Request:    req,
+		}, nil
+	})
+	var event *gojenkins.TraceEvent
+	trace := &gojenkins.TraceConfig{Func: func(e *gojenkins.TraceEvent) { event = e }}
+	client, err := gojenkins.NewClient("http://jenkins.example.com",
+		gojenkins.WithHTTPClient(&http.Client{Transport: transport}),
+		gojenkins.WithoutCrumbs(),
+		gojenkins.WithTrace(trace))
+	assert.Nil(t, err)
+
+	_, err = client.GetJobObj(context.Background(), "app").GetConfig(context.Background())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "connection reset by peer")
+	assert.Equal(t, "<project/>", string(event.ResponseBody))
+}
+
+func TestTraceLegacyDebugKey(t *testing.T) {
+	server := newTraceServer()
+	defer server.Close()
+
+	ctx := context.WithValue(context.Background(), "debug", true)
+	_, err := gojenkins.CreateJenkins(server.Client(), server.URL).GetJob(ctx, "job1")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,76 @@
+package example
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTreeString(t *testing.T) {
+	tree := gojenkins.NewTree(
+		gojenkins.Field("name"),
+		gojenkins.Field("builds", gojenkins.Field("number"), gojenkins.Field("actions", gojenkins.Fields("causes")...)).Range(0, 10),
+		gojenkins.Field("jobs", gojenkins.Fields("name", "color")...).First(5),
+		gojenkins.Field("allBuilds", gojenkins.Fields("number")...).From(100),
+	)
+	assert.Equal(t, "name,builds[number,actions[causes]]{0,10},jobs[name,color]{,5},allBuilds[number]{100,}", tree.String())
+}
+
+func TestQuery(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Write([]byte(`{"name":"app","color":"blue","builds":[{"number":2},{"number":1}]}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := gojenkins.CreateJenkins(server.Client(), server.URL)
+	var out struct {
+		Name   string `json:"name"`
+		Builds []struct {
+			Number int64 `json:"number"`
+		} `json:"builds"`
+	}
+	tree := gojenkins.NewTree(gojenkins.Field("name"), gojenkins.Field("builds", gojenkins.Field("number")).First(2)).Depth(1)
+	err := client.Query(ctx, "/job/app", tree, &out)
+	assert.Nil(t, err)
+	assert.Equal(t, "app", out.Name)
+	assert.Equal(t, 2, len(out.Builds))
+	assert.Equal(t, "name,builds[number]{,2}", queries[0].Get("tree"))
+	assert.Equal(t, "1", queries[0].Get("depth"))
+
+	// The zero Tree fetches the whole document.
+	err = client.Query(ctx, "/job/app", gojenkins.Tree{}, &out)
+	assert.Nil(t, err)
+	assert.Empty(t, queries[1])
+}
+
+func TestPollFields(t *testing.T) {
+	var trees []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trees = append(trees, r.URL.Query().Get("tree"))
+		if r.URL.Query().Get("tree") == "" {
+			w.Write([]byte(`{"name":"app","color":"blue","description":"full"}`))
+			return
+		}
+		w.Write([]byte(`{"name":"app","color":"red"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	job, err := gojenkins.CreateJenkins(server.Client(), server.URL).GetJob(ctx, "app")
+	assert.Nil(t, err)
+	assert.Equal(t, "full", job.GetDescription())
+
+	_, err = job.PollFields(ctx, gojenkins.NewTree(gojenkins.Fields("name", "color")...))
+	assert.Nil(t, err)
+	assert.Equal(t, "red", job.Raw.Color)
+	assert.Equal(t, "", job.GetDescription(), "fields left out are reset")
+	assert.Equal(t, []string{"", "name,color"}, trees)
+}
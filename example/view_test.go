@@ -2,80 +2,90 @@ package example
 
 import (
 	"context"
-	"fmt"
-	gojenkins "github.com/reaperhero/client-jenkins-go"
-	"github.com/reaperhero/client-jenkins-go/utils"
-	"github.com/sirupsen/logrus"
-	"os"
 	"testing"
-)
 
-var (
-	jc  *gojenkins.Jenkins
-	err error
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/reaperhero/client-jenkins-go/utils"
+	"github.com/stretchr/testify/assert"
 )
 
-func init() {
-	if jc, err = gojenkins.CreateJenkins(
-		nil,
-		"https://jenkins.mydomain.com",
-		"jenkins-operator",
-		"1152e8e7a88f6c7ef605844b35t5y6i",
-	).Init(context.Background()); err != nil {
-		panic("init client")
-	}
-}
-
 func TestAddJobToView(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
+	assert.Nil(t, server.AddJob("jobName", jenkinstest.FreeStyleConfig))
 	var (
 		cn = context.Background()
 	)
-	if view, err := jc.GetView(cn, "test_list_view"); err == nil {
-		ok, err := view.AddJob(cn, "jobName")
-		fmt.Println(ok, err)
-	}
+	_, err := jc.CreateView(cn, "test_list_view", utils.DetectViewType("LIST_VIEW"))
+	assert.Nil(t, err)
+
+	view, err := jc.GetView(cn, "test_list_view")
+	assert.Nil(t, err)
+	ok, err := view.AddJob(cn, "jobName")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	view, err = jc.GetView(cn, "test_list_view")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(view.GetJobs()))
 }
 
 func TestCreateFolder(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
 	var (
 		cn = context.Background()
 	)
-	if folder, err := jc.CreateFolder(cn, "test_folder"); err == nil {
-		logrus.Info(folder)
-	}
+	folder, err := jc.CreateFolder(cn, "test_folder")
+	assert.Nil(t, err)
+	assert.Equal(t, "test_folder", folder.GetName())
+	stored, ok := server.Job("test_folder")
+	assert.True(t, ok)
+	assert.True(t, stored.IsFolder())
 }
 
 func TestCreateView(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
+
 	view, err := jc.CreateView(jc.Context, "viewName", utils.DetectViewType("LIST_VIEW"))
-	if err != nil {
-		logrus.Println(err)
-		os.Exit(1)
-	}
-	logrus.Info(view)
+	assert.Nil(t, err)
+	assert.Equal(t, "viewName", view.GetName())
 }
 
 func TestGetPlugins(t *testing.T) {
+	server := jenkinstest.NewServer(jenkinstest.WithPlugins(
+		jenkinstest.Plugin{ShortName: "git", LongName: "Git plugin", Version: "5.2.1"},
+		jenkinstest.Plugin{ShortName: "ant", LongName: "Ant Plugin", Version: "1.0", Disabled: true},
+	))
+	defer server.Close()
+	jc := newFakeClient(t, server)
+
 	pls, err := jc.GetPlugins(jc.Context, 1)
-	if err != nil {
-		return
-	}
+	assert.Nil(t, err)
+	var active []string
 	for _, p := range pls.Raw.Plugins {
 		if len(p.LongName) > 0 && p.Active && p.Enabled {
-			fmt.Printf("    %s - %s ✅\n", p.LongName, p.Version)
+			active = append(active, p.LongName+" - "+p.Version)
 		}
 	}
+	assert.Equal(t, []string{"Git plugin - 5.2.1"}, active)
 }
 
 func TestGetView(t *testing.T) {
-	views, err := jc.GetView(jc.Context, "All")
-	if err != nil {
-		logrus.Error(err)
-		return
-	}
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	jc := newFakeClient(t, server)
+	assert.Nil(t, server.AddJob("jobName", jenkinstest.FreeStyleConfig))
 
+	views, err := jc.GetView(jc.Context, "all")
+	assert.Nil(t, err)
+	var names []string
 	for _, view := range views.Raw.Jobs {
-		fmt.Printf("✅ %s\n", view.Name)
-		fmt.Printf("%s\n", view.Url)
-		fmt.Printf("\n")
+		names = append(names, view.Name)
+		assert.Equal(t, server.URL+"/job/jobName/", view.Url)
 	}
+	assert.Equal(t, []string{"jobName"}, names)
 }
@@ -0,0 +1,88 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/reaperhero/client-jenkins-go"
+	"github.com/reaperhero/client-jenkins-go/jenkinstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkJobs(t *testing.T) {
+	server := jenkinstest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	var requests []string
+	client := newFakeClient(t, server, gojenkins.WithMiddleware(func(next gojenkins.Handler) gojenkins.Handler {
+		return func(ctx context.Context, ar *gojenkins.APIRequest) (*http.Response, error) {
+			requests = append(requests, ar.Endpoint)
+			return next(ctx, ar)
+		}
+	}))
+	for _, folder := range []string{"a", "a/b", "a/b/c", "a/b/c/d"} {
+		assert.Nil(t, server.AddFolder(folder))
+	}
+	assert.Nil(t, server.AddJob("a/b/c/d/deep", jenkinstest.FreeStyleConfig))
+	assert.Nil(t, server.AddJob("a/mb", multibranchConfig))
+	assert.Nil(t, server.AddJob("a/mb/feature%2Flogin", jenkinstest.PipelineConfig))
+	assert.Nil(t, server.AddJob("top", jenkinstest.FreeStyleConfig))
+
+	items := map[string]gojenkins.Item{}
+	var names []string
+	err := client.WalkJobs(ctx, gojenkins.WalkOptions{}, func(item gojenkins.Item) error {
+		items[item.FullName] = item
+		names = append(names, item.FullName)
+		return nil
+	})
+	assert.Nil(t, err)
+	// a/b/c/d is fetched on its own and may come last.
+	sort.Strings(names)
+	assert.Equal(t, []string{"a", "a/b", "a/b/c", "a/b/c/d", "a/b/c/d/deep", "a/mb", "a/mb/feature%2Flogin", "top"}, names)
+	assert.Equal(t, 2, len(requests))
+	assert.Equal(t, gojenkins.Item{FullName: "a/b/c/d/deep", Name: "deep", Class: jenkinstest.FreeStyleClass, URL: server.URL + "/job/a/job/b/job/c/job/d/job/deep/", Color: "notbuilt", Depth: 5}, items["a/b/c/d/deep"])
+	assert.True(t, items["a/b/c/d"].Folder)
+	assert.True(t, items["a/mb"].Folder)
+	assert.False(t, items["top"].Folder)
+
+	var depths []int
+	requests = nil
+	err = client.WalkJobs(ctx, gojenkins.WalkOptions{MaxDepth: 2}, func(item gojenkins.Item) error {
+		depths = append(depths, item.Depth)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 2, 1}, depths)
+	assert.Equal(t, 1, len(requests))
+
+	names = nil
+	err = client.WalkJobs(ctx, gojenkins.WalkOptions{Folder: "a/b", Concurrency: 1}, func(item gojenkins.Item) error {
+		names = append(names, item.FullName)
+		if item.Name == "d" {
+			return gojenkins.SkipFolder
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a/b/c", "a/b/c/d"}, names)
+
+	stop := errors.New("stop")
+	names = nil
+	err = client.WalkJobs(ctx, gojenkins.WalkOptions{}, func(item gojenkins.Item) error {
+		names = append(names, item.FullName)
+		if item.FullName == "a/b/c" {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, []string{"a", "a/b", "a/b/c"}, names)
+
+	err = client.WalkJobs(ctx, gojenkins.WalkOptions{Folder: "missing"}, func(item gojenkins.Item) error {
+		return nil
+	})
+	assert.True(t, errors.Is(err, gojenkins.ErrNotFound))
+}
@@ -0,0 +1,235 @@
+package gojenkins
+
+import (
+	"context"
+	"encoding/xml"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Selector picks the jobs returned by FindJobs. Every condition that is set must
+// hold, the zero Selector matches every job. Folders are searched but not returned.
+type Selector struct {
+	// Folder is the full name of the folder to search, the root if empty.
+	Folder string
+	// MaxDepth stops the search at items of that depth below Folder, 0 means no limit.
+	MaxDepth int
+	// Name is a glob matched with path.Match against the full name, e.g. "team-x/*-deploy".
+	// Like in paths, * does not match the slashes between folders.
+	Name string
+	// NameRegexp is matched against the full name.
+	NameRegexp *regexp.Regexp
+	// Classes are the accepted _class values, e.g. ClassWorkflowJob.
+	Classes []string
+	// Colors are the accepted ball colors like "blue", "red" or "notbuilt".
+	// Running jobs match the color without its _anime suffix.
+	Colors []string
+	// Buildable keeps the jobs that can be built.
+	Buildable bool
+	// Disabled keeps the disabled jobs, Enabled the others.
+	Disabled bool
+	Enabled  bool
+	// NotBuiltFor keeps the jobs whose last build started longer ago, or that were never built.
+	NotBuiltFor time.Duration
+	// BuiltWithin keeps the jobs whose last build started more recently.
+	BuiltWithin time.Duration
+	// Parameters keeps the jobs that define all these build parameters.
+	Parameters []string
+	// Labels keeps the jobs whose label expression, the assignedNode of config.xml,
+	// mentions all these labels.
+	Labels []string
+	// Concurrency limits the requests in flight, 4 if not set.
+	Concurrency int
+}
+
+// JobMatch is a job found by FindJobs, or an error if Err is set.
+type JobMatch struct {
+	Item
+	// LastBuild is when the last build started, zero if the selector did not need
+	// it or the job was never built.
+	LastBuild time.Time
+	Err       error
+}
+
+// jobDetails are the fields fetched for the conditions of a Selector the listing of a folder cannot tell.
+type jobDetails struct {
+	Buildable bool `json:"buildable"`
+	LastBuild *struct {
+		Timestamp int64 `json:"timestamp"`
+	} `json:"lastBuild"`
+	Property []struct {
+		ParameterDefinitions []struct {
+			Name string `json:"name"`
+		} `json:"parameterDefinitions"`
+	} `json:"property"`
+}
+
+// FindJobs searches the jobs matching selector, see WalkJobs, and sends them as
+// they are found. Jobs failing to be checked are sent with Err set, and an error
+// ending the search is sent last with an empty Item. The channel is closed once
+// the search is done. Callers stop reading early by cancelling ctx.
+//
+// Conditions on the listing, like Name, Classes and Colors, are checked first.
+// Buildable, the build times and Parameters take a request per remaining job,
+// Labels another one for config.xml.
+func (j *Jenkins) FindJobs(ctx context.Context, selector Selector) <-chan JobMatch {
+	matches := make(chan JobMatch)
+	concurrency := selector.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultWalkConcurrency
+	}
+	send := func(match JobMatch) {
+		select {
+		case matches <- match:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		defer close(matches)
+		candidates := make(chan Item)
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for item := range candidates {
+					match, ok := j.checkJob(ctx, selector, item)
+					if ok {
+						send(match)
+					}
+				}
+			}()
+		}
+		opts := WalkOptions{Folder: selector.Folder, MaxDepth: selector.MaxDepth, Concurrency: concurrency}
+		err := j.WalkJobs(ctx, opts, func(item Item) error {
+			if item.Folder || !selector.matchItem(item) {
+				return nil
+			}
+			select {
+			case candidates <- item:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(candidates)
+		wg.Wait()
+		if err != nil && ctx.Err() == nil {
+			send(JobMatch{Err: err})
+		}
+	}()
+	return matches
+}
+
+// matchItem checks the conditions the listing of a folder can tell.
+func (s *Selector) matchItem(item Item) bool {
+	if s.Name != "" {
+		if ok, _ := path.Match(s.Name, item.FullName); !ok {
+			return false
+		}
+	}
+	if s.NameRegexp != nil && !s.NameRegexp.MatchString(item.FullName) {
+		return false
+	}
+	if len(s.Classes) > 0 && !containsString(s.Classes, item.Class) {
+		return false
+	}
+	color := strings.TrimSuffix(item.Color, "_anime")
+	if len(s.Colors) > 0 && !containsString(s.Colors, color) {
+		return false
+	}
+	if s.Disabled && color != "disabled" || s.Enabled && color == "disabled" {
+		return false
+	}
+	return true
+}
+
+// checkJob fetches what the conditions left after matchItem need, and checks them.
+func (j *Jenkins) checkJob(ctx context.Context, s Selector, item Item) (JobMatch, bool) {
+	match := JobMatch{Item: item}
+	if s.Buildable || s.NotBuiltFor > 0 || s.BuiltWithin > 0 || len(s.Parameters) > 0 {
+		var details jobDetails
+		tree := NewTree(Field("buildable"), Field("lastBuild", Field("timestamp")), Field("property", Field("parameterDefinitions", Field("name"))))
+		if err := j.Query(ctx, JobPath(item.FullName), tree, &details); err != nil {
+			match.Err = err
+			return match, true
+		}
+		if details.LastBuild != nil {
+			match.LastBuild = time.Unix(0, details.LastBuild.Timestamp*int64(time.Millisecond))
+		}
+		if !s.matchDetails(details, match.LastBuild) {
+			return match, false
+		}
+	}
+	if len(s.Labels) > 0 {
+		job := &Job{Jenkins: j, Raw: new(JobResponse), Base: JobPath(item.FullName)}
+		config, err := job.GetConfig(ctx)
+		if err != nil {
+			match.Err = err
+			return match, true
+		}
+		labels := labelNames(assignedNode(config))
+		for _, label := range s.Labels {
+			if !labels[label] {
+				return match, false
+			}
+		}
+	}
+	return match, true
+}
+
+func (s *Selector) matchDetails(details jobDetails, lastBuild time.Time) bool {
+	if s.Buildable && !details.Buildable {
+		return false
+	}
+	if s.NotBuiltFor > 0 && !lastBuild.IsZero() && time.Since(lastBuild) <= s.NotBuiltFor {
+		return false
+	}
+	if s.BuiltWithin > 0 && (lastBuild.IsZero() || time.Since(lastBuild) > s.BuiltWithin) {
+		return false
+	}
+	parameters := map[string]bool{}
+	for _, property := range details.Property {
+		for _, definition := range property.ParameterDefinitions {
+			parameters[definition.Name] = true
+		}
+	}
+	for _, name := range s.Parameters {
+		if !parameters[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// assignedNode returns the label expression of a job config, "" if it has none.
+func assignedNode(config string) string {
+	var job struct {
+		AssignedNode string `xml:"assignedNode"`
+	}
+	xml.Unmarshal([]byte(xmlDeclaration.ReplaceAllString(config, "")), &job)
+	return job.AssignedNode
+}
+
+// labelNames returns the labels of a label expression like "linux && (docker || podman)".
+func labelNames(expression string) map[string]bool {
+	names := map[string]bool{}
+	for _, name := range strings.FieldsFunc(expression, func(r rune) bool {
+		return strings.ContainsRune(" \t\n&|!()", r)
+	}) {
+		names[strings.Trim(name, `"`)] = true
+	}
+	return names
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
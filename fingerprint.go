@@ -80,3 +80,14 @@ func (f FingerPrint) Poll(ctx context.Context) (int, error) {
 	}
 	return response.StatusCode, nil
 }
+
+// PollFields fetches only the fields selected by tree. Fields left out are zero in Raw.
+func (f FingerPrint) PollFields(ctx context.Context, tree Tree) (int, error) {
+	raw := new(FingerPrintResponse)
+	response, err := f.Jenkins.Requester.GetJSON(ctx, f.Base+f.Id, raw, tree.query())
+	if err != nil {
+		return 0, err
+	}
+	*f.Raw = *raw
+	return response.StatusCode, nil
+}
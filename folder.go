@@ -2,9 +2,7 @@ package gojenkins
 
 import (
 	"context"
-	"errors"
 	"github.com/reaperhero/client-jenkins-go/utils"
-	"strconv"
 	"strings"
 )
 
@@ -34,6 +32,9 @@ func (f *Folder) GetName() string {
 }
 
 func (f *Folder) Create(ctx context.Context, name string) (*Folder, error) {
+	if err := f.Jenkins.requirePlugin("CreateFolder", PluginFolders); err != nil {
+		return nil, err
+	}
 	mode := "com.cloudbees.hudson.plugins.folder.Folder"
 	data := map[string]string{
 		"name":   name,
@@ -52,7 +53,7 @@ func (f *Folder) Create(ctx context.Context, name string) (*Folder, error) {
 		f.Poll(ctx)
 		return f, nil
 	}
-	return nil, errors.New(strconv.Itoa(r.StatusCode))
+	return nil, newAPIError(r)
 }
 
 func (f *Folder) Poll(ctx context.Context) (int, error) {
@@ -62,3 +63,14 @@ func (f *Folder) Poll(ctx context.Context) (int, error) {
 	}
 	return response.StatusCode, nil
 }
+
+// PollFields fetches only the fields selected by tree. Fields left out are zero in Raw.
+func (f *Folder) PollFields(ctx context.Context, tree Tree) (int, error) {
+	raw := new(FolderResponse)
+	response, err := f.Jenkins.Requester.GetJSON(ctx, f.Base, raw, tree.query())
+	if err != nil {
+		return 0, err
+	}
+	f.Raw = raw
+	return response.StatusCode, nil
+}
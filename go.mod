@@ -3,12 +3,12 @@ module github.com/reaperhero/client-jenkins-go
 go 1.16
 
 require (
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/net v0.0.0-20210924151903-3ad01bbaa167
 )
 
 require (
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/sirupsen/logrus v1.8.1 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
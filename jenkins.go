@@ -8,11 +8,11 @@ import (
 	"errors"
 	"fmt"
 	"github.com/reaperhero/client-jenkins-go/utils"
+	"io/ioutil"
 	"log"
 	"net/http"
-	"os"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,27 +23,33 @@ type BasicAuth struct {
 }
 
 type Jenkins struct {
-	Server    string
+	Server string
+	// Version is the version reported by Init, Info or ProbeCapabilities.
+	// Use ParsedVersion while one of them may be running.
 	Version   string
 	Raw       *ExecutorResponse
-	Token     string
+	Token     string // Deprecated: not sent to Jenkins, set Requester.Auth to an APITokenAuth instead.
 	Requester *Requester
 	Context   context.Context
+
+	// capsMu guards caps and Version.
+	capsMu sync.Mutex
+	caps   *Capabilities
 }
 
 // Loggers
+//
+// Deprecated: nothing is logged through them anymore, set Requester.Logger instead.
 var (
-	Info    *log.Logger
-	Warning *log.Logger
-	Error   *log.Logger
+	Info    = log.New(ioutil.Discard, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
+	Warning = log.New(ioutil.Discard, "WARNING: ", log.Ldate|log.Ltime|log.Lshortfile)
+	Error   = log.New(ioutil.Discard, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
 )
 
 // Init Method. Should be called after creating a Jenkins Instance.
 // e.g jenkins,err := CreateJenkins("url").Init()
 // HTTP Client is set here, Connection to jenkins is tested here.
 func (j *Jenkins) Init(ctx context.Context) (*Jenkins, error) {
-	j.initLoggers()
-
 	// Check Connection
 	j.Raw = new(ExecutorResponse)
 	rsp, err := j.Requester.GetJSON(ctx, "/", j.Raw, nil)
@@ -51,7 +57,7 @@ func (j *Jenkins) Init(ctx context.Context) (*Jenkins, error) {
 		return nil, err
 	}
 
-	j.Version = rsp.Header.Get("X-Jenkins")
+	j.setVersion(rsp.Header.Get("X-Jenkins"))
 	if j.Raw == nil || rsp.StatusCode != http.StatusOK {
 		return nil, errors.New("Connection Failed, Please verify that the host and credentials are correct.")
 	}
@@ -59,20 +65,6 @@ func (j *Jenkins) Init(ctx context.Context) (*Jenkins, error) {
 	return j, nil
 }
 
-func (j *Jenkins) initLoggers() {
-	Info = log.New(os.Stdout,
-		"INFO: ",
-		log.Ldate|log.Ltime|log.Lshortfile)
-
-	Warning = log.New(os.Stdout,
-		"WARNING: ",
-		log.Ldate|log.Ltime|log.Lshortfile)
-
-	Error = log.New(os.Stderr,
-		"ERROR: ",
-		log.Ldate|log.Ltime|log.Lshortfile)
-}
-
 // Get Basic Information About Jenkins
 func (j *Jenkins) Info(ctx context.Context) (*ExecutorResponse, error) {
 	rsp, err := j.Requester.GetJSON(ctx, "/", j.Raw, nil)
@@ -81,7 +73,7 @@ func (j *Jenkins) Info(ctx context.Context) (*ExecutorResponse, error) {
 	}
 
 	// The cached version which is set in Init(), might get staled, update
-	j.Version = rsp.Header.Get("X-Jenkins")
+	j.setVersion(rsp.Header.Get("X-Jenkins"))
 
 	return j.Raw, nil
 }
@@ -97,26 +89,23 @@ func (j *Jenkins) SafeRestart(ctx context.Context) error {
 // Example : jenkins.CreateNode("nodeName", 1, "Description", "/var/lib/jenkins", "jdk8 docker", map[string]string{"method": "JNLPLauncher"})
 // By Default JNLPLauncher is created
 // Multiple labels should be separated by blanks
+//
+// Deprecated: use CreateNodeWithOptions.
 func (j *Jenkins) CreateNode(ctx context.Context, name string, numExecutors int, description string, remoteFS string, label string, options ...interface{}) (*Node, error) {
 	params := map[string]string{"method": "JNLPLauncher"}
 
 	if len(options) > 0 {
-		params, _ = options[0].(map[string]string)
-	}
-
-	if _, ok := params["method"]; !ok {
-		params["method"] = "JNLPLauncher"
+		if p, ok := options[0].(map[string]string); ok {
+			params = p
+		}
 	}
 
-	method := params["method"]
-	var launcher map[string]string
-	switch method {
-	case "":
-		fallthrough
-	case "JNLPLauncher":
-		launcher = map[string]string{"stapler-class": "hudson.slaves.JNLPLauncher"}
+	var launcher NodeLauncher
+	switch params["method"] {
+	case "", "JNLPLauncher":
+		launcher = JNLPLauncher{}
 	case "SSHLauncher":
-		launcher = map[string]string{
+		launcher = rawLauncher{
 			"stapler-class":        "hudson.plugins.sshslaves.SSHLauncher",
 			"$class":               "hudson.plugins.sshslaves.SSHLauncher",
 			"host":                 params["host"],
@@ -135,23 +124,48 @@ func (j *Jenkins) CreateNode(ctx context.Context, name string, numExecutors int,
 		return nil, errors.New("launcher method not supported")
 	}
 
-	node := &Node{Jenkins: j, Raw: new(NodeResponse), Base: "/computer/" + name}
+	return j.CreateNodeWithOptions(ctx, CreateNodeOptions{
+		Name:         name,
+		NumExecutors: numExecutors,
+		Description:  description,
+		RemoteFS:     remoteFS,
+		Label:        label,
+		Launcher:     launcher,
+	})
+}
+
+// CreateNodeWithOptions creates a permanent agent, launched with JNLP unless opts.Launcher says otherwise.
+func (j *Jenkins) CreateNodeWithOptions(ctx context.Context, opts CreateNodeOptions) (*Node, error) {
+	if opts.Name == "" {
+		return nil, errors.New("Error Creating Node, node name is missing")
+	}
+	launcher := opts.Launcher
+	if launcher == nil {
+		launcher = JNLPLauncher{}
+	}
+	if launcher.launcherConfig()["stapler-class"] == "hudson.plugins.sshslaves.SSHLauncher" {
+		if err := j.requirePlugin("SSHLauncher", PluginSSHAgents); err != nil {
+			return nil, err
+		}
+	}
+
+	node := &Node{Jenkins: j, Raw: new(NodeResponse), Base: nodePath(opts.Name)}
 	NODE_TYPE := "hudson.slaves.DumbSlave$DescriptorImpl"
 	MODE := "NORMAL"
 	qr := map[string]string{
-		"name": name,
+		"name": opts.Name,
 		"type": NODE_TYPE,
 		"json": utils.MakeJson(map[string]interface{}{
-			"name":               name,
-			"nodeDescription":    description,
-			"remoteFS":           remoteFS,
-			"numExecutors":       numExecutors,
+			"name":               opts.Name,
+			"nodeDescription":    opts.Description,
+			"remoteFS":           opts.RemoteFS,
+			"numExecutors":       opts.NumExecutors,
 			"mode":               MODE,
 			"type":               NODE_TYPE,
-			"labelString":        label,
+			"labelString":        opts.Label,
 			"retentionsStrategy": map[string]string{"stapler-class": "hudson.slaves.RetentionStrategy$Always"},
 			"nodeProperties":     map[string]string{"stapler-class-bag": "true"},
-			"launcher":           launcher,
+			"launcher":           launcher.launcherConfig(),
 		}),
 	}
 
@@ -168,12 +182,12 @@ func (j *Jenkins) CreateNode(ctx context.Context, name string, numExecutors int,
 		}
 		return node, nil
 	}
-	return nil, errors.New(strconv.Itoa(resp.StatusCode))
+	return nil, newAPIError(resp)
 }
 
 // Delete a Jenkins slave node
 func (j *Jenkins) DeleteNode(ctx context.Context, name string) (bool, error) {
-	node := Node{Jenkins: j, Raw: new(NodeResponse), Base: "/computer/" + name}
+	node := Node{Jenkins: j, Raw: new(NodeResponse), Base: nodePath(name)}
 	return node.Delete(ctx)
 }
 
@@ -181,7 +195,7 @@ func (j *Jenkins) DeleteNode(ctx context.Context, name string) (bool, error) {
 // This folder can be nested in other parent folders
 // Example: jenkins.CreateFolder("newFolder", "grandparentFolder", "parentFolder")
 func (j *Jenkins) CreateFolder(ctx context.Context, name string, parents ...string) (*Folder, error) {
-	folderObj := &Folder{Jenkins: j, Raw: new(FolderResponse), Base: "/job/" + strings.Join(append(parents, name), "/job/")}
+	folderObj := &Folder{Jenkins: j, Raw: new(FolderResponse), Base: JobPath(append(parents, name)...)}
 	folder, err := folderObj.Create(ctx, name)
 	if err != nil {
 		return nil, err
@@ -192,7 +206,7 @@ func (j *Jenkins) CreateFolder(ctx context.Context, name string, parents ...stri
 // Create a new job in the folder
 // Example: jenkins.CreateJobInFolder("<config></config>", "newJobName", "myFolder", "parentFolder")
 func (j *Jenkins) CreateJobInFolder(ctx context.Context, config string, jobName string, parentIDs ...string) (*Job, error) {
-	jobObj := Job{Jenkins: j, Raw: new(JobResponse), Base: "/job/" + strings.Join(append(parentIDs, jobName), "/job/")}
+	jobObj := Job{Jenkins: j, Raw: new(JobResponse), Base: JobPath(append(parentIDs, jobName)...)}
 	qr := map[string]string{
 		"name": jobName,
 	}
@@ -207,15 +221,23 @@ func (j *Jenkins) CreateJobInFolder(ctx context.Context, config string, jobName
 // Method takes XML string as first parameter, and if the name is not specified in the config file
 // takes name as string as second parameter
 // e.g jenkins.CreateJob("<config></config>","newJobName")
+//
+// Deprecated: use CreateJobWithOptions.
 func (j *Jenkins) CreateJob(ctx context.Context, config string, options ...interface{}) (*Job, error) {
-	qr := make(map[string]string)
+	var name string
 	if len(options) > 0 {
-		qr["name"] = options[0].(string)
-	} else {
+		name, _ = options[0].(string)
+	}
+	return j.CreateJobWithOptions(ctx, config, CreateJobOptions{Name: name})
+}
+
+// CreateJobWithOptions creates a job from its config.xml, inside opts.Folders if set.
+func (j *Jenkins) CreateJobWithOptions(ctx context.Context, config string, opts CreateJobOptions) (*Job, error) {
+	if opts.Name == "" {
 		return nil, errors.New("Error Creating Job, job name is missing")
 	}
-	jobObj := Job{Jenkins: j, Raw: new(JobResponse), Base: "/job/" + qr["name"]}
-	job, err := jobObj.Create(ctx, config, qr)
+	jobObj := Job{Jenkins: j, Raw: new(JobResponse), Base: JobPath(append(opts.Folders, opts.Name)...)}
+	job, err := jobObj.Create(ctx, config, map[string]string{"name": opts.Name})
 	if err != nil {
 		return nil, err
 	}
@@ -225,7 +247,7 @@ func (j *Jenkins) CreateJob(ctx context.Context, config string, options ...inter
 // Update a job.
 // If a job is exist, update its config
 func (j *Jenkins) UpdateJob(ctx context.Context, job string, config string) *Job {
-	jobObj := Job{Jenkins: j, Raw: new(JobResponse), Base: "/job/" + job}
+	jobObj := Job{Jenkins: j, Raw: new(JobResponse), Base: JobPath(job)}
 	jobObj.UpdateConfig(ctx, config)
 	return &jobObj
 }
@@ -233,7 +255,7 @@ func (j *Jenkins) UpdateJob(ctx context.Context, job string, config string) *Job
 // Rename a job.
 // First parameter job old name, Second parameter job new name.
 func (j *Jenkins) RenameJob(ctx context.Context, job string, name string) *Job {
-	jobObj := Job{Jenkins: j, Raw: new(JobResponse), Base: "/job/" + job}
+	jobObj := Job{Jenkins: j, Raw: new(JobResponse), Base: JobPath(job)}
 	jobObj.Rename(ctx, name)
 	return &jobObj
 }
@@ -241,7 +263,7 @@ func (j *Jenkins) RenameJob(ctx context.Context, job string, name string) *Job {
 // Create a copy of a job.
 // First parameter Name of the job to copy from, Second parameter new job name.
 func (j *Jenkins) CopyJob(ctx context.Context, copyFrom string, newName string) (*Job, error) {
-	job := Job{Jenkins: j, Raw: new(JobResponse), Base: "/job/" + copyFrom}
+	job := Job{Jenkins: j, Raw: new(JobResponse), Base: JobPath(copyFrom)}
 	_, err := job.Poll(ctx)
 	if err != nil {
 		return nil, err
@@ -251,13 +273,13 @@ func (j *Jenkins) CopyJob(ctx context.Context, copyFrom string, newName string)
 
 // Delete a job.
 func (j *Jenkins) DeleteJob(ctx context.Context, name string) (bool, error) {
-	job := Job{Jenkins: j, Raw: new(JobResponse), Base: "/job/" + name}
+	job := Job{Jenkins: j, Raw: new(JobResponse), Base: JobPath(name)}
 	return job.Delete(ctx)
 }
 
 // Get a job object
 func (j *Jenkins) GetJobObj(ctx context.Context, name string) *Job {
-	return &Job{Jenkins: j, Raw: new(JobResponse), Base: "/job/" + name}
+	return &Job{Jenkins: j, Raw: new(JobResponse), Base: JobPath(name)}
 }
 
 // Invoke a job.
@@ -270,14 +292,19 @@ func (j *Jenkins) BuildJob(ctx context.Context, name string, params map[string]s
 
 // A task in queue will be assigned a build number in a job after a few seconds.
 // this function will return the build object.
+// It keeps polling the queue until the build starts or ctx is done.
 func (j *Jenkins) GetBuildFromQueueID(ctx context.Context, job *Job, queueid int64) (*Build, error) {
+	// The queue item is polled until it starts, the response cache would keep it waiting.
+	ctx = ContextWithoutCache(ctx)
 	task, err := j.GetQueueItem(ctx, queueid)
 	if err != nil {
 		return nil, err
 	}
 	// Jenkins queue API has about 4.7second quiet period
 	for task.Raw.Executable.Number == 0 {
-		time.Sleep(1000 * time.Millisecond)
+		if err := sleepContext(ctx, 1000*time.Millisecond); err != nil {
+			return nil, err
+		}
 		_, err = task.Poll(ctx)
 		if err != nil {
 			return nil, err
@@ -292,7 +319,7 @@ func (j *Jenkins) GetBuildFromQueueID(ctx context.Context, job *Job, queueid int
 }
 
 func (j *Jenkins) GetNode(ctx context.Context, name string) (*Node, error) {
-	node := Node{Jenkins: j, Raw: new(NodeResponse), Base: "/computer/" + name}
+	node := Node{Jenkins: j, Raw: new(NodeResponse), Base: nodePath(name)}
 	status, err := node.Poll(ctx)
 	if err != nil {
 		return nil, err
@@ -300,11 +327,11 @@ func (j *Jenkins) GetNode(ctx context.Context, name string) (*Node, error) {
 	if status == 200 {
 		return &node, nil
 	}
-	return nil, errors.New("No node found")
+	return nil, unexpectedStatus("GET", node.Base, status)
 }
 
 func (j *Jenkins) GetLabel(ctx context.Context, name string) (*Label, error) {
-	label := Label{Jenkins: j, Raw: new(LabelResponse), Base: "/label/" + name}
+	label := Label{Jenkins: j, Raw: new(LabelResponse), Base: labelPath(name)}
 	status, err := label.Poll(ctx)
 	if err != nil {
 		return nil, err
@@ -312,7 +339,7 @@ func (j *Jenkins) GetLabel(ctx context.Context, name string) (*Label, error) {
 	if status == 200 {
 		return &label, nil
 	}
-	return nil, errors.New("No label found")
+	return nil, unexpectedStatus("GET", label.Base, status)
 }
 
 func (j *Jenkins) GetBuild(ctx context.Context, jobName string, number int64) (*Build, error) {
@@ -329,7 +356,7 @@ func (j *Jenkins) GetBuild(ctx context.Context, jobName string, number int64) (*
 }
 
 func (j *Jenkins) GetJob(ctx context.Context, id string, parentIDs ...string) (*Job, error) {
-	job := Job{Jenkins: j, Raw: new(JobResponse), Base: "/job/" + strings.Join(append(parentIDs, id), "/job/")}
+	job := Job{Jenkins: j, Raw: new(JobResponse), Base: JobPath(append(parentIDs, id)...)}
 	status, err := job.Poll(ctx)
 	if err != nil {
 		return nil, err
@@ -337,31 +364,31 @@ func (j *Jenkins) GetJob(ctx context.Context, id string, parentIDs ...string) (*
 	if status == 200 {
 		return &job, nil
 	}
-	return nil, errors.New(strconv.Itoa(status))
+	return nil, unexpectedStatus("GET", job.Base, status)
 }
 
 func (j *Jenkins) GetSubJob(ctx context.Context, parentId string, childId string) (*Job, error) {
-	job := Job{Jenkins: j, Raw: new(JobResponse), Base: "/job/" + parentId + "/job/" + childId}
+	job := Job{Jenkins: j, Raw: new(JobResponse), Base: JobPath(parentId, childId)}
 	status, err := job.Poll(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("trouble polling job: %v", err)
+		return nil, fmt.Errorf("trouble polling job: %w", err)
 	}
 	if status == 200 {
 		return &job, nil
 	}
-	return nil, errors.New(strconv.Itoa(status))
+	return nil, unexpectedStatus("GET", job.Base, status)
 }
 
 func (j *Jenkins) GetFolder(ctx context.Context, id string, parents ...string) (*Folder, error) {
-	folder := Folder{Jenkins: j, Raw: new(FolderResponse), Base: "/job/" + strings.Join(append(parents, id), "/job/")}
+	folder := Folder{Jenkins: j, Raw: new(FolderResponse), Base: JobPath(append(parents, id)...)}
 	status, err := folder.Poll(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("trouble polling folder: %v", err)
+		return nil, fmt.Errorf("trouble polling folder: %w", err)
 	}
 	if status == 200 {
 		return &folder, nil
 	}
-	return nil, errors.New(strconv.Itoa(status))
+	return nil, unexpectedStatus("GET", folder.Base, status)
 }
 
 func (j *Jenkins) GetAllNodes(ctx context.Context) ([]*Node, error) {
@@ -378,7 +405,7 @@ func (j *Jenkins) GetAllNodes(ctx context.Context) ([]*Node, error) {
 
 	nodes := make([]*Node, len(computers.Computers))
 	for i, node := range computers.Computers {
-		nodes[i] = &Node{Jenkins: j, Raw: node, Base: "/computer/" + node.DisplayName}
+		nodes[i] = &Node{Jenkins: j, Raw: node, Base: nodePath(node.DisplayName)}
 	}
 
 	return nodes, nil
@@ -479,10 +506,13 @@ func (j *Jenkins) GetPlugins(ctx context.Context, depth int) (*Plugins, error) {
 func (j *Jenkins) UninstallPlugin(ctx context.Context, name string) error {
 	url := fmt.Sprintf("/pluginManager/plugin/%s/doUninstall", name)
 	resp, err := j.Requester.Post(ctx, url, strings.NewReader(""), struct{}{}, map[string]string{})
+	if err != nil {
+		return err
+	}
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("Invalid status code returned: %d", resp.StatusCode)
+		return newAPIError(resp)
 	}
-	return err
+	return nil
 }
 
 // Check if the plugin is installed on the server.
@@ -500,11 +530,13 @@ func (j *Jenkins) HasPlugin(ctx context.Context, name string) (*Plugin, error) {
 func (j *Jenkins) InstallPlugin(ctx context.Context, name string, version string) error {
 	xml := fmt.Sprintf(`<jenkins><install plugin="%s@%s" /></jenkins>`, name, version)
 	resp, err := j.Requester.PostXML(ctx, "/pluginManager/installNecessaryPlugins", xml, j.Raw, map[string]string{})
-
+	if err != nil {
+		return err
+	}
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("Invalid status code returned: %d", resp.StatusCode)
+		return newAPIError(resp)
 	}
-	return err
+	return nil
 }
 
 // Verify FingerPrint
@@ -521,7 +553,7 @@ func (j *Jenkins) ValidateFingerPrint(ctx context.Context, id string) (bool, err
 }
 
 func (j *Jenkins) GetView(ctx context.Context, name string) (*View, error) {
-	url := "/view/" + name
+	url := viewPath(name)
 	view := View{Jenkins: j, Raw: new(ViewResponse), Base: url}
 	_, err := view.Poll(ctx)
 	if err != nil {
@@ -553,7 +585,7 @@ func (j *Jenkins) GetAllViews(ctx context.Context) ([]*View, error) {
 // 		gojenkins.PIPELINE_VIEW
 // Example: jenkins.CreateView("newView",gojenkins.LIST_VIEW)
 func (j *Jenkins) CreateView(ctx context.Context, name string, viewType string) (*View, error) {
-	view := &View{Jenkins: j, Raw: new(ViewResponse), Base: "/view/" + name}
+	view := &View{Jenkins: j, Raw: new(ViewResponse), Base: viewPath(name)}
 	endpoint := "/createView"
 	data := map[string]string{
 		"name":   name,
@@ -573,7 +605,7 @@ func (j *Jenkins) CreateView(ctx context.Context, name string, viewType string)
 	if r.StatusCode == 200 {
 		return j.GetView(ctx, name)
 	}
-	return nil, errors.New(strconv.Itoa(r.StatusCode))
+	return nil, newAPIError(r)
 }
 
 func (j *Jenkins) Poll(ctx context.Context) (int, error) {
@@ -584,24 +616,46 @@ func (j *Jenkins) Poll(ctx context.Context) (int, error) {
 	return resp.StatusCode, nil
 }
 
+// PollFields fetches only the fields selected by tree. Fields left out are zero in Raw.
+func (j *Jenkins) PollFields(ctx context.Context, tree Tree) (int, error) {
+	raw := new(ExecutorResponse)
+	response, err := j.Requester.GetJSON(ctx, "/", raw, tree.query())
+	if err != nil {
+		return 0, err
+	}
+	j.Raw = raw
+	return response.StatusCode, nil
+}
+
 // Creates a new Jenkins Instance
 // Optional parameters are: client, username, password or token
+// An Authenticator can be passed instead of username and password,
+// and TLSOptions to trust a private CA or present a client certificate.
+// Invalid TLSOptions make every request fail with the configuration error.
 // After creating an instance call init method.
+//
+// Deprecated: use NewClient, which takes typed options and reports configuration errors.
 func CreateJenkins(client *http.Client, base string, auth ...interface{}) *Jenkins {
-	if strings.HasSuffix(base, "/") {
-		base = base[:len(base)-1]
-	}
-	if client == nil {
-		client = http.DefaultClient
-	}
-	j := &Jenkins{
-		Server:    base,
-		Requester: &Requester{Base: base, SslVerify: true, Client: client},
-		Context:   context.Background(),
+	options := &clientOptions{client: client}
+	var credentials []string
+	for _, option := range auth {
+		switch v := option.(type) {
+		case string:
+			credentials = append(credentials, v)
+		case Authenticator:
+			options.auth = v
+		case TLSOptions:
+			options.tls = &v
+		case *TLSOptions:
+			if v != nil {
+				options.tls = v
+			}
+		}
 	}
-
-	if len(auth) == 2 {
-		j.Requester.BasicAuth = &BasicAuth{Username: auth[0].(string), Password: auth[1].(string)}
+	if len(credentials) == 2 {
+		options.basicAuth = &BasicAuth{Username: credentials[0], Password: credentials[1]}
 	}
+	j, err := newJenkins(base, options)
+	j.Requester.configErr = err
 	return j
 }
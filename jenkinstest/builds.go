@@ -0,0 +1,389 @@
+package jenkinstest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Build results.
+const (
+	ResultSuccess  = "SUCCESS"
+	ResultUnstable = "UNSTABLE"
+	ResultFailure  = "FAILURE"
+	ResultAborted  = "ABORTED"
+)
+
+// Build is a build of a job. Result is empty while the build is running.
+type Build struct {
+	Job         string
+	Number      int64
+	QueueID     int64
+	Building    bool
+	Result      string
+	Description string
+	Parameters  map[string]string
+	Log         string
+	Timestamp   time.Time
+	Duration    time.Duration
+	// Stages are reported by the Pipeline REST API, see AddStage.
+	Stages []Stage
+}
+
+// Stage is a stage of a Pipeline run. Status is one of the wfapi states,
+// e.g. SUCCESS, FAILED or IN_PROGRESS.
+type Stage struct {
+	ID       string
+	Name     string
+	Status   string
+	Duration time.Duration
+}
+
+// StartBuild takes the oldest queue item of job and starts it. It returns the build number.
+func (s *Server) StartBuild(job string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range s.queue {
+		if item.Job == job {
+			return s.start(item).Number, nil
+		}
+	}
+	return 0, fmt.Errorf("jenkinstest: no queued build of %q", job)
+}
+
+// StartQueued starts every queued build, as if executors became free.
+func (s *Server) StartQueued() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.queue) > 0 {
+		s.start(s.queue[0])
+	}
+}
+
+// AppendLog adds text to the console log of a running build.
+func (s *Server) AppendLog(job string, number int64, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	build, err := s.runningBuild(job, number)
+	if err != nil {
+		return err
+	}
+	build.Log += text
+	return nil
+}
+
+// AddStage adds a Pipeline stage to a build, for the wfapi endpoints.
+func (s *Server) AddStage(job string, number int64, name string, status string, duration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	build := s.findBuild(job, number)
+	if build == nil {
+		return fmt.Errorf("jenkinstest: no build %s #%d", job, number)
+	}
+	build.Stages = append(build.Stages, Stage{
+		ID:       strconv.Itoa(len(build.Stages) + 1),
+		Name:     name,
+		Status:   status,
+		Duration: duration,
+	})
+	return nil
+}
+
+// FinishBuild completes a running build with result, e.g. ResultSuccess.
+func (s *Server) FinishBuild(job string, number int64, result string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	build, err := s.runningBuild(job, number)
+	if err != nil {
+		return err
+	}
+	s.finish(build, result)
+	return nil
+}
+
+// AddBuild records a completed build of job without going through the queue,
+// to set up a build history. It returns the build number.
+func (s *Server) AddBuild(job string, result string, log string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[job]
+	if !ok || j.IsFolder() {
+		return 0, fmt.Errorf("jenkinstest: no job %q", job)
+	}
+	build := s.newBuild(j, 0, nil)
+	build.Log = log
+	s.finish(build, result)
+	return build.Number, nil
+}
+
+// Build returns a copy of build number of job.
+func (s *Server) Build(job string, number int64) (Build, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	build := s.findBuild(job, number)
+	if build == nil {
+		return Build{}, false
+	}
+	return build.copy(), true
+}
+
+// Builds returns copies of the builds of job, oldest first.
+func (s *Server) Builds(job string) []Build {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var builds []Build
+	for _, build := range s.builds[job] {
+		builds = append(builds, build.copy())
+	}
+	return builds
+}
+
+func (b *Build) copy() Build {
+	copied := *b
+	copied.Stages = append([]Stage(nil), b.Stages...)
+	if b.Parameters != nil {
+		copied.Parameters = map[string]string{}
+		for k, v := range b.Parameters {
+			copied.Parameters[k] = v
+		}
+	}
+	return copied
+}
+
+func (s *Server) findBuild(job string, number int64) *Build {
+	for _, build := range s.builds[job] {
+		if build.Number == number {
+			return build
+		}
+	}
+	return nil
+}
+
+func (s *Server) runningBuild(job string, number int64) (*Build, error) {
+	build := s.findBuild(job, number)
+	if build == nil {
+		return nil, fmt.Errorf("jenkinstest: no build %s #%d", job, number)
+	}
+	if !build.Building {
+		return nil, fmt.Errorf("jenkinstest: build %s #%d is not running", job, number)
+	}
+	return build, nil
+}
+
+// start moves item out of the queue and starts its build.
+func (s *Server) start(item *QueueItem) *Build {
+	build := s.newBuild(s.jobs[item.Job], item.ID, item.Parameters)
+	build.Building = true
+	item.BuildNumber = build.Number
+	item.Why = ""
+	s.dequeue(func(queued *QueueItem) bool { return queued == item })
+	s.left = append(s.left, item)
+	return build
+}
+
+func (s *Server) newBuild(job *Job, queueID int64, parameters map[string]string) *Build {
+	build := &Build{
+		Job:        job.FullName,
+		Number:     job.NextBuildNumber,
+		QueueID:    queueID,
+		Parameters: parameters,
+		Timestamp:  s.now(),
+	}
+	job.NextBuildNumber++
+	s.builds[job.FullName] = append(s.builds[job.FullName], build)
+	return build
+}
+
+func (s *Server) finish(build *Build, result string) {
+	build.Building = false
+	build.Result = result
+	build.Duration = s.now().Sub(build.Timestamp)
+	for i := range build.Stages {
+		if build.Stages[i].Status == stageInProgress {
+			build.Stages[i].Status = stageStatus(result)
+		}
+	}
+}
+
+func (s *Server) buildURL(build *Build) string {
+	return s.jobURL(build.Job) + strconv.FormatInt(build.Number, 10) + "/"
+}
+
+func (s *Server) buildRef(job *Job, build *Build) map[string]interface{} {
+	return map[string]interface{}{"_class": buildClasses[job.Class], "number": build.Number, "url": s.buildURL(build)}
+}
+
+// serveBuild handles the requests below /job/NAME/NUMBER.
+func (s *Server) serveBuild(w http.ResponseWriter, req *request, job *Job, number int64, segments []string) {
+	build := s.findBuild(job.FullName, number)
+	if build == nil {
+		notFound(w)
+		return
+	}
+	post := req.Method == http.MethodPost
+	switch action := strings.Join(segments, "/"); {
+	case action == "" && req.api && req.Method == http.MethodGet:
+		s.writeJSON(w, req, s.buildJSON(job, build))
+	case action == "consoleText" && req.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "text/plain;charset=utf-8")
+		fmt.Fprint(w, build.Log)
+	case action == "logText/progressiveText" && req.Method == http.MethodGet:
+		start := queryInt(req.URL.Query(), "start")
+		if start < 0 || start > int64(len(build.Log)) {
+			start = int64(len(build.Log))
+		}
+		w.Header().Set("Content-Type", "text/plain;charset=utf-8")
+		w.Header().Set("X-Text-Size", strconv.Itoa(len(build.Log)))
+		if build.Building {
+			w.Header().Set("X-More-Data", "true")
+		}
+		fmt.Fprint(w, build.Log[start:])
+	case (action == "stop" || action == "term" || action == "kill") && post:
+		if build.Building {
+			s.finish(build, ResultAborted)
+		}
+	case action == "submitDescription" && post:
+		build.Description = formValue(req, "description")
+	case action == "doDelete" && post:
+		builds := s.builds[job.FullName]
+		for i, b := range builds {
+			if b == build {
+				s.builds[job.FullName] = append(builds[:i], builds[i+1:]...)
+				break
+			}
+		}
+	case action == "wfapi/describe" && req.Method == http.MethodGet && job.Class == PipelineClass:
+		s.writeJSON(w, req, s.runJSON(job, build))
+	case action == "wfapi/pendingInputActions" && req.Method == http.MethodGet && job.Class == PipelineClass:
+		s.writeJSON(w, req, []interface{}{})
+	case len(segments) == 5 && segments[0] == "execution" && segments[1] == "node" && segments[3] == "wfapi" && segments[4] == "describe" && job.Class == PipelineClass:
+		for _, stage := range build.Stages {
+			if stage.ID == segments[2] {
+				node := s.stageJSON(job, build, stage)
+				node["stageFlowNodes"] = []interface{}{}
+				s.writeJSON(w, req, node)
+				return
+			}
+		}
+		notFound(w)
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) buildJSON(job *Job, build *Build) map[string]interface{} {
+	actions := []interface{}{
+		map[string]interface{}{
+			"_class": "hudson.model.CauseAction",
+			"causes": []interface{}{map[string]interface{}{
+				"_class":           "hudson.model.Cause$RemoteCause",
+				"shortDescription": "Started by remote host 127.0.0.1",
+			}},
+		},
+	}
+	if len(build.Parameters) > 0 {
+		names := make([]string, 0, len(build.Parameters))
+		for name := range build.Parameters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parameters := []interface{}{}
+		for _, name := range names {
+			parameters = append(parameters, map[string]interface{}{
+				"_class": "hudson.model.StringParameterValue",
+				"name":   name,
+				"value":  build.Parameters[name],
+			})
+		}
+		actions = append(actions, map[string]interface{}{"_class": "hudson.model.ParametersAction", "parameters": parameters})
+	}
+	var result interface{}
+	if !build.Building {
+		result = build.Result
+	}
+	var description interface{}
+	if build.Description != "" {
+		description = build.Description
+	}
+	name := "#" + strconv.FormatInt(build.Number, 10)
+	data := map[string]interface{}{
+		"_class":            buildClasses[job.Class],
+		"actions":           actions,
+		"artifacts":         []interface{}{},
+		"building":          build.Building,
+		"description":       description,
+		"displayName":       name,
+		"duration":          build.Duration.Milliseconds(),
+		"estimatedDuration": -1,
+		"executor":          nil,
+		"fullDisplayName":   strings.Replace(job.FullName, "/", " » ", -1) + " " + name,
+		"id":                strconv.FormatInt(build.Number, 10),
+		"keepLog":           false,
+		"number":            build.Number,
+		"queueId":           build.QueueID,
+		"result":            result,
+		"timestamp":         build.Timestamp.UnixNano() / int64(time.Millisecond),
+		"url":               s.buildURL(build),
+		"culprits":          []interface{}{},
+	}
+	if job.Class == FreeStyleClass {
+		data["builtOn"] = ""
+		data["changeSet"] = map[string]interface{}{"_class": "hudson.scm.EmptyChangeLogSet", "items": []interface{}{}, "kind": nil}
+	} else {
+		data["changeSets"] = []interface{}{}
+	}
+	return data
+}
+
+const stageInProgress = "IN_PROGRESS"
+
+// stageStatus converts a build result into a wfapi status.
+func stageStatus(result string) string {
+	switch result {
+	case "":
+		return stageInProgress
+	case ResultFailure:
+		return "FAILED"
+	default:
+		return result
+	}
+}
+
+func (s *Server) runJSON(job *Job, build *Build) map[string]interface{} {
+	stages := []interface{}{}
+	for _, stage := range build.Stages {
+		stages = append(stages, s.stageJSON(job, build, stage))
+	}
+	start := build.Timestamp.UnixNano() / int64(time.Millisecond)
+	duration := build.Duration.Milliseconds()
+	if build.Building {
+		duration = s.now().Sub(build.Timestamp).Milliseconds()
+	}
+	path := strings.TrimPrefix(s.buildURL(build), s.URL)
+	return map[string]interface{}{
+		"_links":          map[string]interface{}{"self": map[string]interface{}{"href": path + "wfapi/describe"}},
+		"id":              strconv.FormatInt(build.Number, 10),
+		"name":            "#" + strconv.FormatInt(build.Number, 10),
+		"status":          stageStatus(build.Result),
+		"startTimeMillis": start,
+		"endTimeMillis":   start + duration,
+		"durationMillis":  duration,
+		"stages":          stages,
+	}
+}
+
+func (s *Server) stageJSON(job *Job, build *Build, stage Stage) map[string]interface{} {
+	path := strings.TrimPrefix(s.buildURL(build), s.URL)
+	return map[string]interface{}{
+		"_links":          map[string]interface{}{"self": map[string]interface{}{"href": path + "execution/node/" + stage.ID + "/wfapi/describe"}},
+		"id":              stage.ID,
+		"name":            stage.Name,
+		"status":          stage.Status,
+		"startTimeMillis": build.Timestamp.UnixNano() / int64(time.Millisecond),
+		"durationMillis":  stage.Duration.Milliseconds(),
+		"parentNodes":     []interface{}{},
+	}
+}
@@ -0,0 +1,339 @@
+package jenkinstest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// CassetteMode selects whether a Cassette talks to Jenkins or plays a recording back.
+type CassetteMode int
+
+const (
+	// ModeReplay answers requests from the cassette file and never touches the network.
+	ModeReplay CassetteMode = iota
+	// ModeRecord sends requests to Jenkins and records them, for Save to write them to the file.
+	ModeRecord
+)
+
+// Redacted replaces credentials, crumbs and secrets in recorded interactions.
+const Redacted = "REDACTED"
+
+// originPlaceholder replaces the scheme and host of Jenkins in recorded responses,
+// so that URLs in a recording point to the client replaying it.
+const originPlaceholder = "{{origin}}"
+
+// ErrNoInteraction is returned in replay mode for a request that was not recorded.
+var ErrNoInteraction = errors.New("jenkinstest: no recorded interaction")
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the part of a request kept in a cassette. Query is normalized,
+// see NormalizeQuery.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	// BodyBase64 is set when Body holds binary data, encoded with base64.
+	BodyBase64 bool `json:"bodyBase64,omitempty"`
+}
+
+// RecordedResponse is a recorded response.
+type RecordedResponse struct {
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 bool        `json:"bodyBase64,omitempty"`
+}
+
+type cassetteFile struct {
+	Source       string        `json:"source,omitempty"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Cassette is an http.RoundTripper recording interactions with Jenkins to a file,
+// or playing them back in tests without a Jenkins instance, e.g.
+//
+//	cassette, err := jenkinstest.NewCassette("testdata/deploy.json", jenkinstest.ModeReplay, nil)
+//	jenkins, err := gojenkins.NewClient("http://jenkins", gojenkins.WithHTTPClient(cassette.Client()))
+//
+// Requests match a recording on the method, the path and the normalized query.
+// The host of Jenkins is not recorded, URLs in responses point to the replaying client.
+// When the same request was recorded more than once, the recordings are served in order
+// and the last one is repeated after that.
+type Cassette struct {
+	// Redact is called on every interaction before it is recorded, to remove
+	// secrets the built-in redaction does not know about.
+	Redact func(*Interaction)
+	// Source tells what the interactions were recorded against, e.g. "Jenkins 2.440.1"
+	// or a jenkinstest server. It is saved with them and read back in replay mode.
+	Source string
+
+	path         string
+	mode         CassetteMode
+	next         http.RoundTripper
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewCassette creates a cassette for the file at path. In replay mode the file is read
+// right away. In record mode requests are sent through next, http.DefaultTransport if nil.
+func NewCassette(path string, mode CassetteMode, next http.RoundTripper) (*Cassette, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	c := &Cassette{path: path, mode: mode, next: next}
+	if mode == ModeRecord {
+		return c, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("jenkinstest: invalid cassette %s: %w", path, err)
+	}
+	c.Source = file.Source
+	c.interactions = file.Interactions
+	c.used = make([]bool, len(file.Interactions))
+	return c, nil
+}
+
+// Mode returns the mode of the cassette.
+func (c *Cassette) Mode() CassetteMode {
+	return c.mode
+}
+
+// Client returns an http.Client sending its requests through the cassette.
+// Redirects are followed by the client, so each hop is recorded on its own.
+func (c *Cassette) Client() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// Interactions returns the recorded interactions.
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interaction(nil), c.interactions...)
+}
+
+// Save writes the recorded interactions to the cassette file. It does nothing in replay mode.
+func (c *Cassette) Save() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+	c.mu.Lock()
+	data, err := json.MarshalIndent(cassetteFile{Source: c.Source, Interactions: c.interactions}, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, append(data, '\n'), 0644)
+}
+
+// RoundTrip records or replays req.
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	if c.mode == ModeRecord {
+		return c.record(req)
+	}
+	return c.replay(req)
+}
+
+func (c *Cassette) record(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		if requestBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+	response, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  NormalizeQuery(req.URL.Query()),
+			Header: redactHeader(req.Header),
+		},
+		Response: RecordedResponse{
+			StatusCode: response.StatusCode,
+			Header:     redactHeader(response.Header),
+		},
+	}
+	// Redaction changes the length of bodies, replay sets it from the recorded body.
+	interaction.Response.Header.Del("Content-Length")
+	origin := []byte(req.URL.Scheme + "://" + req.URL.Host)
+	for _, values := range interaction.Response.Header {
+		for i := range values {
+			values[i] = strings.Replace(values[i], string(origin), originPlaceholder, -1)
+		}
+	}
+	responseBody = bytes.Replace(responseBody, origin, []byte(originPlaceholder), -1)
+	interaction.Request.Body, interaction.Request.BodyBase64 = encodeBody(redactBody(requestBody, req.Header.Get("Content-Type")))
+	interaction.Response.Body, interaction.Response.BodyBase64 = encodeBody(redactBody(responseBody, response.Header.Get("Content-Type")))
+	if c.Redact != nil {
+		c.Redact(&interaction)
+	}
+
+	c.mu.Lock()
+	c.interactions = append(c.interactions, interaction)
+	c.used = append(c.used, true)
+	c.mu.Unlock()
+	return response, nil
+}
+
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		ioutil.ReadAll(req.Body)
+		req.Body.Close()
+	}
+	query := NormalizeQuery(req.URL.Query())
+
+	c.mu.Lock()
+	found := -1
+	for i, interaction := range c.interactions {
+		if interaction.Request.Method != req.Method || interaction.Request.Path != req.URL.Path || interaction.Request.Query != query {
+			continue
+		}
+		found = i
+		if !c.used[i] {
+			break
+		}
+	}
+	if found < 0 {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("%w for %s %s", ErrNoInteraction, req.Method, req.URL.RequestURI())
+	}
+	c.used[found] = true
+	recorded := c.interactions[found].Response
+	c.mu.Unlock()
+
+	body, err := decodeBody(recorded.Body, recorded.BodyBase64)
+	if err != nil {
+		return nil, err
+	}
+	origin := req.URL.Scheme + "://" + req.URL.Host
+	body = bytes.Replace(body, []byte(originPlaceholder), []byte(origin), -1)
+	header := http.Header{}
+	for k, values := range recorded.Header {
+		for _, value := range values {
+			header.Add(k, strings.Replace(value, originPlaceholder, origin, -1))
+		}
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(recorded.StatusCode) + " " + http.StatusText(recorded.StatusCode),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// NormalizeQuery encodes a query with sorted keys and secret values redacted,
+// the form in which queries are recorded and matched.
+func NormalizeQuery(query url.Values) string {
+	normalized := url.Values{}
+	for key, values := range query {
+		for _, value := range values {
+			if sensitiveName.MatchString(key) {
+				value = Redacted
+			}
+			normalized.Add(key, value)
+		}
+	}
+	return normalized.Encode()
+}
+
+var (
+	// sensitiveName matches the names of headers, form fields and query parameters holding secrets.
+	sensitiveName = regexp.MustCompile(`(?i)passw|secret|token|passphrase|private_?key|api_?key|crumb|cookie|authorization`)
+	// sensitiveXML matches the elements of credentials in config.xml.
+	sensitiveXML = regexp.MustCompile(`(?s)<(password|secret|passphrase|privateKey|secretBytes|clientKey|apiToken)>[^<]*<`)
+	// sensitiveJSON matches secret string values in JSON, e.g. the crumb issued by /crumbIssuer.
+	sensitiveJSON = regexp.MustCompile(`"(crumb|password|secret|token|apiToken)"(\s*:\s*)"[^"]*"`)
+)
+
+func redactHeader(header http.Header) http.Header {
+	redacted := http.Header{}
+	for name, values := range header {
+		for _, value := range values {
+			if sensitiveName.MatchString(name) {
+				value = Redacted
+			}
+			redacted.Add(name, value)
+		}
+	}
+	return redacted
+}
+
+func redactBody(body []byte, contentType string) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	if !strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		body = sensitiveXML.ReplaceAll(body, []byte("<$1>"+Redacted+"<"))
+		return sensitiveJSON.ReplaceAll(body, []byte(`"$1"$2"`+Redacted+`"`))
+	}
+	if form, err := url.ParseQuery(string(body)); err == nil {
+		for key := range form {
+			if sensitiveName.MatchString(key) {
+				form.Set(key, Redacted)
+			}
+		}
+		body = []byte(form.Encode())
+	}
+	return body
+}
+
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+func decodeBody(body string, isBase64 bool) ([]byte, error) {
+	if isBase64 {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
@@ -0,0 +1,168 @@
+package jenkinstest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// globalDomain is the URL name of the domain holding credentials without a domain.
+const globalDomain = "_"
+
+// credentialStore is the system store or the store of a folder, by domain and id.
+type credentialStore struct {
+	domains map[string]*credentialDomain
+}
+
+type credentialDomain struct {
+	ids    []string
+	config map[string]string
+}
+
+// secretElements match the secret values that Jenkins redacts when serving config.xml.
+var secretElements = regexp.MustCompile(`(?s)<(password|secret|passphrase|privateKey|secretBytes|clientKey)>.*?</(password|secret|passphrase|privateKey|secretBytes|clientKey)>`)
+
+// AddCredential stores the credential config in domain, "_" for the global domain.
+// folder is the full name of the folder holding the credential, empty for the system store.
+func (s *Server) AddCredential(folder string, domain string, config string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addCredential(folder, domain, config)
+}
+
+// Credential returns the stored config.xml of a credential, with its secrets.
+func (s *Server) Credential(folder string, domain string, id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.credentialDomain(folder, domain)
+	if d == nil {
+		return "", false
+	}
+	config, ok := d.config[id]
+	return config, ok
+}
+
+func (s *Server) credentialDomain(folder string, domain string) *credentialDomain {
+	store, ok := s.credentials[folder]
+	if !ok {
+		store = &credentialStore{domains: map[string]*credentialDomain{}}
+		s.credentials[folder] = store
+	}
+	if domain != globalDomain {
+		return store.domains[domain]
+	}
+	if store.domains[domain] == nil {
+		store.domains[domain] = &credentialDomain{config: map[string]string{}}
+	}
+	return store.domains[domain]
+}
+
+func (s *Server) addCredential(folder string, domain string, config string) error {
+	d := s.credentialDomain(folder, domain)
+	if d == nil {
+		return fmt.Errorf("jenkinstest: no credentials domain %q", domain)
+	}
+	root, err := parseXML(strings.NewReader(config))
+	if err != nil {
+		return err
+	}
+	id := root.text("id")
+	if id == "" {
+		return fmt.Errorf("jenkinstest: credential has no id")
+	}
+	if _, exists := d.config[id]; exists {
+		return errCredentialExists
+	}
+	d.ids = append(d.ids, id)
+	d.config[id] = config
+	return nil
+}
+
+var errCredentialExists = errors.New("jenkinstest: credential already exists")
+
+// serveCredentials handles the requests below credentials/store/STORE/domain/DOMAIN
+// of the system or a folder.
+func (s *Server) serveCredentials(w http.ResponseWriter, req *request, folder string, segments []string) {
+	store := "system"
+	if folder != "" {
+		store = "folder"
+	}
+	if len(segments) < 4 || segments[0] != "store" || segments[1] != store || segments[2] != "domain" {
+		notFound(w)
+		return
+	}
+	if !s.hasPlugin("credentials") {
+		notFound(w)
+		return
+	}
+	domainName := segments[3]
+	domain := s.credentialDomain(folder, domainName)
+	if domain == nil {
+		notFound(w)
+		return
+	}
+	segments = segments[4:]
+	post := req.Method == http.MethodPost
+
+	switch {
+	case len(segments) == 0 && req.api && req.Method == http.MethodGet:
+		credentials := []interface{}{}
+		for _, id := range domain.ids {
+			credentials = append(credentials, map[string]interface{}{
+				"_class": "com.cloudbees.plugins.credentials.CredentialsStoreAction$CredentialsWrapper",
+				"id":     id,
+			})
+		}
+		s.writeJSON(w, req, map[string]interface{}{
+			"_class":      "com.cloudbees.plugins.credentials.CredentialsStoreAction$DomainWrapper",
+			"credentials": credentials,
+		})
+	case len(segments) == 1 && segments[0] == "createCredentials" && post:
+		body, _ := ioutil.ReadAll(req.Body)
+		switch err := s.addCredential(folder, domainName, string(body)); {
+		case err == errCredentialExists:
+			http.Error(w, err.Error(), http.StatusConflict)
+		case err != nil:
+			badRequest(w, err.Error())
+		}
+	case len(segments) == 3 && segments[0] == "credential":
+		id := segments[1]
+		config, ok := domain.config[id]
+		if !ok {
+			notFound(w)
+			return
+		}
+		switch {
+		case segments[2] == "config.xml" && req.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprint(w, secretElements.ReplaceAllString(config, "<$1><secret-redacted/></$1>"))
+		case segments[2] == "config.xml" && post:
+			body, _ := ioutil.ReadAll(req.Body)
+			root, err := parseXML(strings.NewReader(string(body)))
+			if err != nil {
+				badRequest(w, err.Error())
+				return
+			}
+			if newID := root.text("id"); newID != "" && newID != id {
+				badRequest(w, "The credential id cannot be changed")
+				return
+			}
+			domain.config[id] = string(body)
+		case segments[2] == "doDelete" && post:
+			delete(domain.config, id)
+			for i, existing := range domain.ids {
+				if existing == id {
+					domain.ids = append(domain.ids[:i], domain.ids[i+1:]...)
+					break
+				}
+			}
+		default:
+			notFound(w)
+		}
+	default:
+		notFound(w)
+	}
+}
@@ -0,0 +1,671 @@
+package jenkinstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Configurations of the job types known to the fake, for AddJob.
+const (
+	FreeStyleConfig = `<?xml version='1.1' encoding='UTF-8'?>
+<project>
+  <description></description>
+  <keepDependencies>false</keepDependencies>
+  <properties/>
+  <canRoam>true</canRoam>
+  <disabled>false</disabled>
+  <builders/>
+  <publishers/>
+  <buildWrappers/>
+</project>`
+	PipelineConfig = `<?xml version='1.1' encoding='UTF-8'?>
+<flow-definition plugin="workflow-job">
+  <description></description>
+  <keepDependencies>false</keepDependencies>
+  <properties/>
+  <definition class="org.jenkinsci.plugins.workflow.cps.CpsFlowDefinition" plugin="workflow-cps">
+    <script>node { echo 'hello' }</script>
+    <sandbox>true</sandbox>
+  </definition>
+  <disabled>false</disabled>
+</flow-definition>`
+	FolderConfig = `<?xml version='1.1' encoding='UTF-8'?>
+<com.cloudbees.hudson.plugins.folder.Folder plugin="cloudbees-folder">
+  <description></description>
+  <properties/>
+</com.cloudbees.hudson.plugins.folder.Folder>`
+)
+
+// Item classes reported in _class.
+const (
+	FreeStyleClass = "hudson.model.FreeStyleProject"
+	PipelineClass  = "org.jenkinsci.plugins.workflow.job.WorkflowJob"
+	FolderClass    = "com.cloudbees.hudson.plugins.folder.Folder"
+	MatrixClass    = "hudson.matrix.MatrixProject"
+)
+
+// itemClasses maps the root element of config.xml to the item class.
+var itemClasses = map[string]string{
+	"project":         FreeStyleClass,
+	"flow-definition": PipelineClass,
+	"matrix-project":  MatrixClass,
+}
+
+var buildClasses = map[string]string{
+	FreeStyleClass: "hudson.model.FreeStyleBuild",
+	PipelineClass:  "org.jenkinsci.plugins.workflow.job.WorkflowRun",
+	MatrixClass:    "hudson.matrix.MatrixBuild",
+}
+
+// Job is a job or folder, keyed by its full name, e.g. "team/app/deploy".
+type Job struct {
+	FullName string
+	Class    string
+	Config   string
+	// Parameters are read from the parameterDefinitions in Config.
+	Parameters      []Parameter
+	Description     string
+	Disabled        bool
+	NextBuildNumber int64
+}
+
+// Parameter is a build parameter definition of a job.
+type Parameter struct {
+	Class        string
+	Name         string
+	Description  string
+	DefaultValue string
+}
+
+// IsFolder reports whether the item holds other items.
+func (j *Job) IsFolder() bool {
+	return j.Class == FolderClass || strings.HasSuffix(j.Class, "MultiBranchProject") || strings.HasSuffix(j.Class, "OrganizationFolder")
+}
+
+func (j *Job) name() string {
+	return j.FullName[strings.LastIndex(j.FullName, "/")+1:]
+}
+
+func (j *Job) parent() string {
+	if i := strings.LastIndex(j.FullName, "/"); i >= 0 {
+		return j.FullName[:i]
+	}
+	return ""
+}
+
+// AddJob creates the job fullName from config, e.g. FreeStyleConfig or PipelineConfig.
+// The parent folders must exist.
+func (s *Server) AddJob(fullName string, config string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addJob(fullName, config)
+}
+
+// AddFolder creates the folder fullName and any missing parent folders.
+func (s *Server) AddFolder(fullName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := strings.Split(fullName, "/")
+	for i := range names {
+		name := strings.Join(names[:i+1], "/")
+		if job, ok := s.jobs[name]; ok {
+			if !job.IsFolder() {
+				return fmt.Errorf("jenkinstest: %q is not a folder", name)
+			}
+			continue
+		}
+		if err := s.addJob(name, FolderConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Job returns a copy of the job fullName.
+func (s *Server) Job(fullName string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[fullName]
+	if !ok {
+		return Job{}, false
+	}
+	copied := *job
+	copied.Parameters = append([]Parameter(nil), job.Parameters...)
+	return copied, true
+}
+
+// Jobs returns the full names of all jobs and folders, sorted.
+func (s *Server) Jobs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.jobs))
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) addJob(fullName string, config string) error {
+	if fullName == "" || strings.HasPrefix(fullName, "/") || strings.HasSuffix(fullName, "/") {
+		return fmt.Errorf("jenkinstest: invalid job name %q", fullName)
+	}
+	if _, ok := s.jobs[fullName]; ok {
+		return fmt.Errorf("jenkinstest: a job already exists with the name %q", fullName)
+	}
+	job := &Job{FullName: fullName, NextBuildNumber: 1}
+	if parent := job.parent(); parent != "" {
+		if folder, ok := s.jobs[parent]; !ok || !folder.IsFolder() {
+			return fmt.Errorf("jenkinstest: no folder %q", parent)
+		}
+	}
+	if err := job.setConfig(config); err != nil {
+		return err
+	}
+	s.jobs[fullName] = job
+	return nil
+}
+
+// setConfig stores config and reads the job class, description, state and parameters from it.
+func (j *Job) setConfig(config string) error {
+	root, err := parseXML(strings.NewReader(config))
+	if err != nil {
+		return fmt.Errorf("jenkinstest: invalid config.xml: %w", err)
+	}
+	class, ok := itemClasses[root.XMLName.Local]
+	if !ok {
+		class = root.XMLName.Local
+	}
+	if j.Class != "" && j.Class != class {
+		return fmt.Errorf("jenkinstest: cannot change %s into %s", j.Class, class)
+	}
+	j.Class = class
+	j.Config = config
+	j.Description = root.text("description")
+	j.Disabled = root.text("disabled") == "true"
+	j.Parameters = nil
+	for _, definitions := range root.find("parameterDefinitions") {
+		for _, definition := range definitions.Nodes {
+			j.Parameters = append(j.Parameters, Parameter{
+				Class:        definition.XMLName.Local,
+				Name:         definition.text("name"),
+				Description:  definition.text("description"),
+				DefaultValue: definition.text("defaultValue"),
+			})
+		}
+	}
+	return nil
+}
+
+func (s *Server) jobURL(fullName string) string {
+	var b strings.Builder
+	b.WriteString(s.URL)
+	for _, name := range strings.Split(fullName, "/") {
+		b.WriteString("/job/" + url.PathEscape(name))
+	}
+	return b.String() + "/"
+}
+
+func (s *Server) children(folder string) []*Job {
+	var children []*Job
+	for _, job := range s.jobs {
+		if job.parent() == folder {
+			children = append(children, job)
+		}
+	}
+	sort.Slice(children, func(i, k int) bool { return children[i].FullName < children[k].FullName })
+	return children
+}
+
+// innerJobs lists the items of folder. Nested lists the items of its folders too,
+// for tree queries like jobs[name,jobs[name]].
+func (s *Server) innerJobs(folder string, nested bool) []interface{} {
+	jobs := []interface{}{}
+	for _, job := range s.children(folder) {
+		jobs = append(jobs, s.innerJob(job, nested))
+	}
+	return jobs
+}
+
+func (s *Server) innerJob(job *Job, nested bool) map[string]interface{} {
+	inner := map[string]interface{}{"_class": job.Class, "name": job.name(), "url": s.jobURL(job.FullName)}
+	switch {
+	case !job.IsFolder():
+		inner["color"] = s.color(job)
+	case nested:
+		inner["jobs"] = s.innerJobs(job.FullName, true)
+	}
+	return inner
+}
+
+// color is the ball color of a job, e.g. blue_anime for a successful job that is building again.
+func (s *Server) color(job *Job) string {
+	if job.Disabled {
+		return "disabled"
+	}
+	color := "notbuilt"
+	building := false
+	builds := s.builds[job.FullName]
+	for i := len(builds) - 1; i >= 0; i-- {
+		if builds[i].Building {
+			building = true
+			continue
+		}
+		color = map[string]string{
+			ResultSuccess:  "blue",
+			ResultUnstable: "yellow",
+			ResultFailure:  "red",
+			ResultAborted:  "aborted",
+		}[builds[i].Result]
+		break
+	}
+	if building {
+		color += "_anime"
+	}
+	return color
+}
+
+// serveJob handles the requests below /job/NAME/job/NAME/...
+func (s *Server) serveJob(w http.ResponseWriter, req *request) {
+	segments := req.segments
+	var names []string
+	for len(segments) >= 2 && segments[0] == "job" {
+		names = append(names, segments[1])
+		segments = segments[2:]
+	}
+	fullName := strings.Join(names, "/")
+	job, ok := s.jobs[fullName]
+	if !ok {
+		notFound(w)
+		return
+	}
+	if len(segments) == 0 {
+		if req.api && req.Method == http.MethodGet {
+			s.writeJSON(w, req, s.jobJSON(job, req.hasTree()))
+			return
+		}
+		notFound(w)
+		return
+	}
+	if number, ok := s.buildNumber(job, segments[0]); ok {
+		s.serveBuild(w, req, job, number, segments[1:])
+		return
+	}
+
+	post := req.Method == http.MethodPost
+	switch action := strings.Join(segments, "/"); {
+	case action == "config.xml" && req.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, job.Config)
+	case action == "config.xml" && post:
+		body, _ := ioutil.ReadAll(req.Body)
+		if err := job.setConfig(string(body)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	case action == "createItem" && post && job.IsFolder():
+		s.createItem(w, req, fullName)
+	case segments[0] == "credentials" && job.IsFolder():
+		s.serveCredentials(w, req, fullName, segments[1:])
+	case action == "enable" && post:
+		job.Disabled = false
+	case action == "disable" && post:
+		job.Disabled = true
+	case action == "doDelete" && post:
+		s.deleteJob(fullName)
+	case action == "doRename" && post:
+		s.renameJob(w, job, formValue(req, "newName"))
+	case action == "description" && req.Method == http.MethodGet:
+		fmt.Fprint(w, job.Description)
+	case action == "submitDescription" && post:
+		job.Description = formValue(req, "description")
+	case (action == "build" || action == "buildWithParameters") && post:
+		s.triggerBuild(w, req, job, action == "buildWithParameters")
+	case action == "wfapi/runs" && req.Method == http.MethodGet:
+		runs := []interface{}{}
+		builds := s.builds[fullName]
+		for i := len(builds) - 1; i >= 0; i-- {
+			runs = append(runs, s.runJSON(job, builds[i]))
+		}
+		s.writeJSON(w, req, runs)
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) jobJSON(job *Job, nested bool) map[string]interface{} {
+	data := map[string]interface{}{
+		"_class":            job.Class,
+		"actions":           []interface{}{},
+		"description":       job.Description,
+		"displayName":       job.name(),
+		"displayNameOrNull": nil,
+		"fullDisplayName":   strings.Replace(job.FullName, "/", " » ", -1),
+		"fullName":          job.FullName,
+		"name":              job.name(),
+		"url":               s.jobURL(job.FullName),
+		"healthReport":      []interface{}{},
+	}
+	if job.IsFolder() {
+		data["jobs"] = s.innerJobs(job.FullName, nested)
+		view := map[string]interface{}{"_class": allViewClass, "name": allView, "url": s.jobURL(job.FullName)}
+		data["primaryView"] = view
+		data["views"] = []interface{}{view}
+		return data
+	}
+
+	builds := s.builds[job.FullName]
+	// Tree queries can select the fields of the builds, like lastBuild[number,timestamp].
+	ref := func(build *Build) map[string]interface{} {
+		if nested {
+			return s.buildJSON(job, build)
+		}
+		return s.buildRef(job, build)
+	}
+	buildRefs := []interface{}{}
+	for i := len(builds) - 1; i >= 0; i-- {
+		buildRefs = append(buildRefs, ref(builds[i]))
+	}
+	last := func(match func(*Build) bool) interface{} {
+		for i := len(builds) - 1; i >= 0; i-- {
+			if match(builds[i]) {
+				return ref(builds[i])
+			}
+		}
+		return nil
+	}
+	var first interface{}
+	if len(builds) > 0 {
+		first = ref(builds[0])
+	}
+	var queueItem interface{}
+	for _, item := range s.queue {
+		if item.Job == job.FullName {
+			queueItem = s.queueItemJSON(item)
+		}
+	}
+	properties := []interface{}{}
+	if len(job.Parameters) > 0 {
+		definitions := []interface{}{}
+		for _, p := range job.Parameters {
+			definitions = append(definitions, map[string]interface{}{
+				"_class":                p.Class,
+				"defaultParameterValue": map[string]interface{}{"_class": parameterValueClass(p.Class), "name": p.Name, "value": p.DefaultValue},
+				"description":           p.Description,
+				"name":                  p.Name,
+				"type":                  p.Class[strings.LastIndex(p.Class, ".")+1:],
+			})
+		}
+		properties = append(properties, map[string]interface{}{
+			"_class":               "hudson.model.ParametersDefinitionProperty",
+			"parameterDefinitions": definitions,
+		})
+	}
+
+	// Jenkins only sends allBuilds when the tree asks for it, the fake always does.
+	data["allBuilds"] = buildRefs
+	data["buildable"] = !job.Disabled
+	data["builds"] = buildRefs
+	data["color"] = s.color(job)
+	data["concurrentBuild"] = false
+	data["downstreamProjects"] = []interface{}{}
+	data["firstBuild"] = first
+	data["inQueue"] = queueItem != nil
+	data["keepDependencies"] = false
+	data["lastBuild"] = last(func(*Build) bool { return true })
+	data["lastCompletedBuild"] = last(func(b *Build) bool { return !b.Building })
+	data["lastFailedBuild"] = last(func(b *Build) bool { return b.Result == ResultFailure })
+	data["lastStableBuild"] = last(func(b *Build) bool { return b.Result == ResultSuccess })
+	data["lastSuccessfulBuild"] = last(func(b *Build) bool { return b.Result == ResultSuccess || b.Result == ResultUnstable })
+	data["lastUnstableBuild"] = last(func(b *Build) bool { return b.Result == ResultUnstable })
+	data["lastUnsuccessfulBuild"] = last(func(b *Build) bool { return !b.Building && b.Result != ResultSuccess })
+	data["nextBuildNumber"] = job.NextBuildNumber
+	data["property"] = properties
+	data["queueItem"] = queueItem
+	data["scm"] = map[string]interface{}{"_class": "hudson.scm.NullSCM"}
+	data["upstreamProjects"] = []interface{}{}
+	return data
+}
+
+func parameterValueClass(definitionClass string) string {
+	return strings.TrimSuffix(definitionClass, "Definition") + "Value"
+}
+
+// createItem handles createItem in folder, which creates a job from config.xml,
+// copies a job in mode=copy or creates a folder when mode names the folder class.
+func (s *Server) createItem(w http.ResponseWriter, req *request, folder string) {
+	if req.Method != http.MethodPost {
+		notFound(w)
+		return
+	}
+	name := formValue(req, "name")
+	if name == "" {
+		badRequest(w, "No name is specified")
+		return
+	}
+	if strings.ContainsAny(name, "/\\?*%:|\"<>[]") {
+		badRequest(w, fmt.Sprintf("‘%s’ is an unsafe character", name))
+		return
+	}
+	fullName := name
+	if folder != "" {
+		fullName = folder + "/" + name
+	}
+	if _, exists := s.jobs[fullName]; exists {
+		badRequest(w, fmt.Sprintf("A job already exists with the name ‘%s’", name))
+		return
+	}
+
+	var config string
+	switch mode := formValue(req, "mode"); mode {
+	case "":
+		body, _ := ioutil.ReadAll(req.Body)
+		config = string(body)
+	case "copy":
+		from := formValue(req, "from")
+		if folder != "" && !strings.Contains(from, "/") {
+			from = folder + "/" + from
+		}
+		source, ok := s.jobs[strings.TrimPrefix(from, "/")]
+		if !ok {
+			badRequest(w, "No such job: "+from)
+			return
+		}
+		config = source.Config
+	case FolderClass:
+		config = FolderConfig
+	default:
+		badRequest(w, "No item type "+mode)
+		return
+	}
+	if err := s.addJob(fullName, config); err != nil {
+		badRequest(w, err.Error())
+	}
+}
+
+func (s *Server) deleteJob(fullName string) {
+	for name := range s.jobs {
+		if name == fullName || strings.HasPrefix(name, fullName+"/") {
+			delete(s.jobs, name)
+			delete(s.builds, name)
+		}
+	}
+	s.dequeue(func(item *QueueItem) bool {
+		return item.Job == fullName || strings.HasPrefix(item.Job, fullName+"/")
+	})
+}
+
+func (s *Server) renameJob(w http.ResponseWriter, job *Job, newName string) {
+	if newName == "" || strings.Contains(newName, "/") {
+		badRequest(w, "Invalid name "+newName)
+		return
+	}
+	oldName := job.FullName
+	newFullName := newName
+	if parent := job.parent(); parent != "" {
+		newFullName = parent + "/" + newName
+	}
+	if _, exists := s.jobs[newFullName]; exists {
+		badRequest(w, fmt.Sprintf("A job already exists with the name ‘%s’", newName))
+		return
+	}
+	for name, j := range s.jobs {
+		if name != oldName && !strings.HasPrefix(name, oldName+"/") {
+			continue
+		}
+		renamed := newFullName + strings.TrimPrefix(name, oldName)
+		delete(s.jobs, name)
+		j.FullName = renamed
+		s.jobs[renamed] = j
+		if builds, ok := s.builds[name]; ok {
+			delete(s.builds, name)
+			s.builds[renamed] = builds
+		}
+	}
+	for _, item := range s.queue {
+		if item.Job == oldName || strings.HasPrefix(item.Job, oldName+"/") {
+			item.Job = newFullName + strings.TrimPrefix(item.Job, oldName)
+		}
+	}
+}
+
+// triggerBuild queues a build. Like Jenkins, a request for a job that is
+// already queued with the same parameters answers with the queued item.
+func (s *Server) triggerBuild(w http.ResponseWriter, req *request, job *Job, withParameters bool) {
+	if job.IsFolder() {
+		notFound(w)
+		return
+	}
+	if job.Disabled {
+		http.Error(w, "Job is disabled", http.StatusConflict)
+		return
+	}
+	if withParameters && len(job.Parameters) == 0 {
+		badRequest(w, fmt.Sprintf("%s is not parameterized", job.FullName))
+		return
+	}
+	var given map[string]string
+	if len(job.Parameters) > 0 {
+		var err error
+		if given, err = buildParameters(req, withParameters); err != nil {
+			badRequest(w, err.Error())
+			return
+		}
+	}
+
+	var parameters map[string]string
+	if len(job.Parameters) > 0 {
+		parameters = map[string]string{}
+		for _, p := range job.Parameters {
+			parameters[p.Name] = p.DefaultValue
+			if value, ok := given[p.Name]; ok {
+				parameters[p.Name] = value
+			}
+		}
+	}
+
+	item := s.findQueued(job.FullName, parameters)
+	if item == nil {
+		item = &QueueItem{ID: s.nextQueueID, Job: job.FullName, Parameters: parameters, Why: "Waiting for next available executor"}
+		s.nextQueueID++
+		s.queue = append(s.queue, item)
+		if s.autoStart {
+			if build := s.start(item); s.autoResult != "" {
+				s.finish(build, s.autoResult)
+			}
+		}
+	}
+	w.Header().Set("Location", fmt.Sprintf("%s/queue/item/%d/", s.URL, item.ID))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// buildParameters reads the parameters sent to /buildWithParameters as form fields,
+// or to /build as the json form field.
+func buildParameters(req *request, withParameters bool) (map[string]string, error) {
+	parameters := map[string]string{}
+	if raw := formValue(req, "json"); raw != "" {
+		var form struct {
+			Parameter json.RawMessage `json:"parameter"`
+		}
+		if err := json.Unmarshal([]byte(raw), &form); err != nil {
+			return nil, err
+		}
+		if len(form.Parameter) == 0 {
+			// gojenkins sends the parameters as a plain object.
+			var plain map[string]interface{}
+			if err := json.Unmarshal([]byte(raw), &plain); err != nil {
+				return nil, err
+			}
+			for name, value := range plain {
+				parameters[name] = fmt.Sprint(value)
+			}
+			return parameters, nil
+		}
+		var list []struct {
+			Name  string      `json:"name"`
+			Value interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(form.Parameter, &list); err != nil {
+			var single struct {
+				Name  string      `json:"name"`
+				Value interface{} `json:"value"`
+			}
+			if err := json.Unmarshal(form.Parameter, &single); err != nil {
+				return nil, err
+			}
+			list = append(list, single)
+		}
+		for _, p := range list {
+			parameters[p.Name] = fmt.Sprint(p.Value)
+		}
+		return parameters, nil
+	}
+	if !withParameters {
+		return nil, fmt.Errorf("Nothing is submitted")
+	}
+	for name, values := range req.Form {
+		if len(values) > 0 {
+			parameters[name] = values[0]
+		}
+	}
+	return parameters, nil
+}
+
+// buildNumber resolves a build number or a permalink like lastBuild.
+func (s *Server) buildNumber(job *Job, segment string) (int64, bool) {
+	if number, err := strconv.ParseInt(segment, 10, 64); err == nil {
+		return number, true
+	}
+	builds := s.builds[job.FullName]
+	matches := map[string]func(*Build) bool{
+		"lastBuild":             func(*Build) bool { return true },
+		"lastCompletedBuild":    func(b *Build) bool { return !b.Building },
+		"lastSuccessfulBuild":   func(b *Build) bool { return b.Result == ResultSuccess || b.Result == ResultUnstable },
+		"lastStableBuild":       func(b *Build) bool { return b.Result == ResultSuccess },
+		"lastFailedBuild":       func(b *Build) bool { return b.Result == ResultFailure },
+		"lastUnstableBuild":     func(b *Build) bool { return b.Result == ResultUnstable },
+		"lastUnsuccessfulBuild": func(b *Build) bool { return !b.Building && b.Result != ResultSuccess },
+	}
+	if segment == "firstBuild" {
+		if len(builds) == 0 {
+			return 0, false
+		}
+		return builds[0].Number, true
+	}
+	match, ok := matches[segment]
+	if !ok {
+		return 0, false
+	}
+	for i := len(builds) - 1; i >= 0; i-- {
+		if match(builds[i]) {
+			return builds[i].Number, true
+		}
+	}
+	// An unknown build number answers 404 further down.
+	return -1, true
+}
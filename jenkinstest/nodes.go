@@ -0,0 +1,264 @@
+package jenkinstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// builtInNode is the name of the controller's own node in /computer.
+const builtInNode = "(built-in)"
+
+// Node is an agent. Agents created through the API are offline until
+// they connect, see SetNodeOnline.
+type Node struct {
+	Name         string
+	Description  string
+	NumExecutors int
+	RemoteFS     string
+	// Label holds the node labels, separated by blanks.
+	Label string
+	// Launcher is the stapler-class of the launcher, e.g. hudson.slaves.JNLPLauncher.
+	Launcher           string
+	Online             bool
+	TemporarilyOffline bool
+	OfflineMessage     string
+}
+
+// AddNode adds an agent, e.g. to test code that lists or deletes nodes.
+func (s *Server) AddNode(node Node) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if node.Name == "" {
+		return fmt.Errorf("jenkinstest: node name is missing")
+	}
+	if _, exists := s.nodes[node.Name]; exists {
+		return fmt.Errorf("jenkinstest: agent called %q already exists", node.Name)
+	}
+	s.nodes[node.Name] = &node
+	return nil
+}
+
+// SetNodeOnline connects or disconnects the agent name.
+func (s *Server) SetNodeOnline(name string, online bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	node, ok := s.nodes[name]
+	if !ok {
+		return fmt.Errorf("jenkinstest: no node %q", name)
+	}
+	node.Online = online
+	return nil
+}
+
+// Node returns a copy of the agent name.
+func (s *Server) Node(name string) (Node, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if node, ok := s.nodes[name]; ok {
+		return *node, true
+	}
+	return Node{}, false
+}
+
+func (s *Server) nodeNames() []string {
+	names := make([]string, 0, len(s.nodes))
+	for name := range s.nodes {
+		if name != builtInNode {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{builtInNode}, names...)
+}
+
+// serveComputer handles the requests below /computer.
+func (s *Server) serveComputer(w http.ResponseWriter, req *request, segments []string) {
+	post := req.Method == http.MethodPost
+	if len(segments) == 0 {
+		if !req.api || req.Method != http.MethodGet {
+			notFound(w)
+			return
+		}
+		computers := []interface{}{}
+		total := 0
+		for _, name := range s.nodeNames() {
+			computers = append(computers, s.computerJSON(s.nodes[name]))
+			total += s.nodes[name].NumExecutors
+		}
+		s.writeJSON(w, req, map[string]interface{}{
+			"_class":         "hudson.model.ComputerSet",
+			"busyExecutors":  0,
+			"computer":       computers,
+			"displayName":    "Nodes",
+			"totalExecutors": total,
+		})
+		return
+	}
+	if segments[0] == "doCreateItem" && post {
+		s.createNode(w, req)
+		return
+	}
+
+	node, ok := s.nodes[segments[0]]
+	if !ok {
+		notFound(w)
+		return
+	}
+	switch action := strings.Join(segments[1:], "/"); {
+	case action == "" && req.api && req.Method == http.MethodGet:
+		s.writeJSON(w, req, s.computerJSON(node))
+	case action == "doDelete" && post:
+		if node.Name == builtInNode {
+			badRequest(w, "The built-in node cannot be deleted")
+			return
+		}
+		delete(s.nodes, node.Name)
+	case action == "toggleOffline" && post:
+		node.TemporarilyOffline = !node.TemporarilyOffline
+		node.OfflineMessage = ""
+		if node.TemporarilyOffline {
+			node.OfflineMessage = formValue(req, "offlineMessage")
+		}
+	case action == "launchSlaveAgent" && post:
+		node.Online = true
+	case action == "doDisconnect" && post:
+		if node.Name != builtInNode {
+			node.Online = false
+		}
+	default:
+		notFound(w)
+	}
+}
+
+// createNode handles /computer/doCreateItem, which sends the agent as the json form field.
+func (s *Server) createNode(w http.ResponseWriter, req *request) {
+	name := formValue(req, "name")
+	if name == "" {
+		badRequest(w, "Agent name is missing")
+		return
+	}
+	if _, exists := s.nodes[name]; exists {
+		badRequest(w, fmt.Sprintf("Agent called ‘%s’ already exists", name))
+		return
+	}
+	var form struct {
+		NodeDescription string      `json:"nodeDescription"`
+		RemoteFS        string      `json:"remoteFS"`
+		NumExecutors    json.Number `json:"numExecutors"`
+		LabelString     string      `json:"labelString"`
+		Launcher        struct {
+			StaplerClass string `json:"stapler-class"`
+		} `json:"launcher"`
+	}
+	if err := json.Unmarshal([]byte(formValue(req, "json")), &form); err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	executors, _ := form.NumExecutors.Int64()
+	if executors < 1 {
+		executors = 1
+	}
+	s.nodes[name] = &Node{
+		Name:         name,
+		Description:  form.NodeDescription,
+		NumExecutors: int(executors),
+		RemoteFS:     form.RemoteFS,
+		Label:        form.LabelString,
+		Launcher:     form.Launcher.StaplerClass,
+	}
+}
+
+func (s *Server) computerJSON(node *Node) map[string]interface{} {
+	class := "hudson.slaves.SlaveComputer"
+	displayName := node.Name
+	if node.Name == builtInNode {
+		class = "hudson.model.Hudson$MasterComputer"
+		displayName = "Built-In Node"
+	}
+	executors := []interface{}{}
+	for i := 0; i < node.NumExecutors; i++ {
+		executors = append(executors, map[string]interface{}{"currentExecutable": nil})
+	}
+	offline := !node.Online || node.TemporarilyOffline
+	var offlineCause interface{}
+	if node.TemporarilyOffline {
+		offlineCause = map[string]interface{}{"_class": "hudson.slaves.OfflineCause$UserCause"}
+	}
+	return map[string]interface{}{
+		"_class":              class,
+		"actions":             []interface{}{},
+		"displayName":         displayName,
+		"executors":           executors,
+		"icon":                "symbol-computer",
+		"iconClassName":       "symbol-computer",
+		"idle":                true,
+		"jnlpAgent":           node.Launcher == "hudson.slaves.JNLPLauncher",
+		"launchSupported":     node.Launcher != "hudson.slaves.JNLPLauncher",
+		"loadStatistics":      map[string]interface{}{"_class": "hudson.model.Label$1"},
+		"manualLaunchAllowed": true,
+		"monitorData":         map[string]interface{}{},
+		"numExecutors":        node.NumExecutors,
+		"offline":             offline,
+		"offlineCause":        offlineCause,
+		"offlineCauseReason":  node.OfflineMessage,
+		"oneOffExecutors":     []interface{}{},
+		"temporarilyOffline":  node.TemporarilyOffline,
+	}
+}
+
+// serveLabel handles /label/NAME, listing the nodes that have the label.
+func (s *Server) serveLabel(w http.ResponseWriter, req *request, segments []string) {
+	if len(segments) != 1 || !req.api || req.Method != http.MethodGet {
+		notFound(w)
+		return
+	}
+	label := segments[0]
+	nodes := []interface{}{}
+	offline := true
+	total := 0
+	for _, name := range s.nodeNames() {
+		node := s.nodes[name]
+		if name != label && !hasLabel(node.Label, label) {
+			continue
+		}
+		nodeName := node.Name
+		if name == builtInNode {
+			nodeName = ""
+		}
+		nodes = append(nodes, map[string]interface{}{
+			"_class":          "hudson.slaves.DumbSlave",
+			"nodeName":        nodeName,
+			"nodeDescription": node.Description,
+			"numExecutors":    node.NumExecutors,
+			"mode":            "NORMAL",
+		})
+		total += node.NumExecutors
+		offline = offline && (!node.Online || node.TemporarilyOffline)
+	}
+	if len(nodes) == 0 {
+		notFound(w)
+		return
+	}
+	s.writeJSON(w, req, map[string]interface{}{
+		"_class":         "hudson.model.labels.LabelAtom",
+		"name":           label,
+		"description":    nil,
+		"nodes":          nodes,
+		"offline":        offline,
+		"idleExecutors":  total,
+		"busyExecutors":  0,
+		"totalExecutors": total,
+	})
+}
+
+func hasLabel(labels string, label string) bool {
+	for _, l := range strings.Fields(labels) {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
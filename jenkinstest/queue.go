@@ -0,0 +1,176 @@
+package jenkinstest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// QueueItem is a build request. It leaves the queue when its build starts,
+// BuildNumber is set from then on, or when it is cancelled.
+type QueueItem struct {
+	ID          int64
+	Job         string
+	Parameters  map[string]string
+	Why         string
+	Cancelled   bool
+	BuildNumber int64
+}
+
+// Queue returns copies of the items waiting in the queue, oldest first.
+func (s *Server) Queue() []QueueItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []QueueItem
+	for _, item := range s.queue {
+		items = append(items, *item)
+	}
+	return items
+}
+
+// QueueItem returns a copy of the queue item id, which may have left the queue.
+func (s *Server) QueueItem(id int64) (QueueItem, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if item := s.findQueueItem(id); item != nil {
+		return *item, true
+	}
+	return QueueItem{}, false
+}
+
+func (s *Server) findQueueItem(id int64) *QueueItem {
+	for _, items := range [][]*QueueItem{s.queue, s.left} {
+		for _, item := range items {
+			if item.ID == id {
+				return item
+			}
+		}
+	}
+	return nil
+}
+
+// findQueued returns the waiting item of job with the same parameters.
+func (s *Server) findQueued(job string, parameters map[string]string) *QueueItem {
+	for _, item := range s.queue {
+		if item.Job == job && sameParameters(item.Parameters, parameters) {
+			return item
+		}
+	}
+	return nil
+}
+
+func sameParameters(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if value, ok := b[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// dequeue removes the waiting items matching remove.
+func (s *Server) dequeue(remove func(*QueueItem) bool) {
+	queue := s.queue[:0]
+	for _, item := range s.queue {
+		if !remove(item) {
+			queue = append(queue, item)
+		}
+	}
+	s.queue = queue
+}
+
+// serveQueue handles the requests below /queue.
+func (s *Server) serveQueue(w http.ResponseWriter, req *request, segments []string) {
+	switch {
+	case len(segments) == 0 && req.api && req.Method == http.MethodGet:
+		items := []interface{}{}
+		for _, item := range s.queue {
+			items = append(items, s.queueItemJSON(item))
+		}
+		s.writeJSON(w, req, map[string]interface{}{
+			"_class":            "hudson.model.Queue",
+			"discoverableItems": []interface{}{},
+			"items":             items,
+		})
+	case len(segments) == 2 && segments[0] == "item" && req.api && req.Method == http.MethodGet:
+		id, _ := strconv.ParseInt(segments[1], 10, 64)
+		item := s.findQueueItem(id)
+		if item == nil {
+			notFound(w)
+			return
+		}
+		s.writeJSON(w, req, s.queueItemJSON(item))
+	case len(segments) == 1 && segments[0] == "cancelItem" && req.Method == http.MethodPost:
+		id := queryInt(req.URL.Query(), "id")
+		for _, item := range s.queue {
+			if item.ID == id {
+				item.Cancelled = true
+				item.Why = ""
+				s.dequeue(func(queued *QueueItem) bool { return queued == item })
+				s.left = append(s.left, item)
+				return
+			}
+		}
+		notFound(w)
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) queueItemJSON(item *QueueItem) map[string]interface{} {
+	class := "hudson.model.Queue$WaitingItem"
+	var executable interface{}
+	if item.Cancelled || item.BuildNumber != 0 {
+		class = "hudson.model.Queue$LeftItem"
+	}
+	if build := s.findBuild(item.Job, item.BuildNumber); build != nil {
+		executable = map[string]interface{}{"_class": buildClasses[s.jobs[item.Job].Class], "number": build.Number, "url": s.buildURL(build)}
+	}
+	actions := []interface{}{
+		map[string]interface{}{
+			"_class": "hudson.model.CauseAction",
+			"causes": []interface{}{map[string]interface{}{
+				"_class":           "hudson.model.Cause$RemoteCause",
+				"shortDescription": "Started by remote host 127.0.0.1",
+			}},
+		},
+	}
+	if len(item.Parameters) > 0 {
+		names := make([]string, 0, len(item.Parameters))
+		for name := range item.Parameters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parameters := []interface{}{}
+		for _, name := range names {
+			parameters = append(parameters, map[string]interface{}{"_class": "hudson.model.StringParameterValue", "name": name, "value": item.Parameters[name]})
+		}
+		actions = append(actions, map[string]interface{}{"_class": "hudson.model.ParametersAction", "parameters": parameters})
+	}
+	task := map[string]interface{}{"name": item.Job, "url": s.URL + "/"}
+	if job, ok := s.jobs[item.Job]; ok {
+		task = map[string]interface{}{"_class": job.Class, "name": job.name(), "url": s.jobURL(job.FullName), "color": s.color(job)}
+	}
+	data := map[string]interface{}{
+		"_class":       class,
+		"actions":      actions,
+		"blocked":      false,
+		"buildable":    item.BuildNumber == 0 && !item.Cancelled,
+		"id":           item.ID,
+		"inQueueSince": 0,
+		"params":       "",
+		"stuck":        false,
+		"task":         task,
+		"url":          "queue/item/" + strconv.FormatInt(item.ID, 10) + "/",
+		"why":          nil,
+		"cancelled":    item.Cancelled,
+		"executable":   executable,
+	}
+	if item.Why != "" {
+		data["why"] = item.Why
+	}
+	return data
+}
@@ -0,0 +1,416 @@
+// Package jenkinstest runs an in-memory fake Jenkins for unit tests.
+//
+// The fake serves the REST endpoints used by gojenkins over an httptest server:
+// jobs and folders, builds, the queue, crumbs, credentials, nodes, views,
+// plugins and the Pipeline REST API. Builds do not run by themselves, tests
+// move them along with StartBuild, AppendLog and FinishBuild, e.g.
+//
+//	server := jenkinstest.NewServer()
+//	defer server.Close()
+//	server.AddJob("deploy", jenkinstest.FreeStyleConfig)
+//
+//	jenkins, _ := server.NewClient()
+//	queueID, _ := jenkins.BuildJob(ctx, "deploy", nil)
+//	number, _ := server.StartBuild("deploy")
+//	server.AppendLog("deploy", number, "deploying\n")
+//	server.FinishBuild("deploy", number, jenkinstest.ResultSuccess)
+package jenkinstest
+
+import (
+	"encoding/json"
+	"github.com/reaperhero/client-jenkins-go"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultVersion is the Jenkins version reported in the X-Jenkins header.
+const DefaultVersion = "2.426.3"
+
+// CrumbField is the header carrying the CSRF crumb.
+const CrumbField = "Jenkins-Crumb"
+
+// DefaultPlugins are installed unless WithPlugins says otherwise.
+var DefaultPlugins = []Plugin{
+	{ShortName: "cloudbees-folder", LongName: "Folders Plugin", Version: "6.858.v898218f3609d"},
+	{ShortName: "credentials", LongName: "Credentials Plugin", Version: "1319.v7eb_51b_3a_c97b_"},
+	{ShortName: "plain-credentials", LongName: "Plain Credentials Plugin", Version: "143.v1b_df8b_d3b_e48"},
+	{ShortName: "ssh-slaves", LongName: "SSH Build Agents plugin", Version: "2.916.vd17b_43357ce4"},
+	{ShortName: "workflow-job", LongName: "Pipeline: Job", Version: "1385.vb_58b_86ea_fff1"},
+	{ShortName: "pipeline-rest-api", LongName: "Pipeline: REST API Plugin", Version: "2.34"},
+}
+
+// Plugin is a plugin reported by /pluginManager.
+type Plugin struct {
+	ShortName string
+	LongName  string
+	Version   string
+	// Disabled plugins are listed as installed but not active.
+	Disabled bool
+}
+
+// Option configures a Server created by NewServer.
+type Option func(*Server)
+
+// WithVersion reports version in the X-Jenkins header instead of DefaultVersion.
+func WithVersion(version string) Option {
+	return func(s *Server) {
+		s.version = version
+	}
+}
+
+// WithPlugins installs plugins instead of DefaultPlugins.
+func WithPlugins(plugins ...Plugin) Option {
+	return func(s *Server) {
+		s.plugins = append([]Plugin(nil), plugins...)
+	}
+}
+
+// WithUser enables security and lets username log in with password.
+// Password logins have to send a crumb with every POST.
+func WithUser(username string, password string) Option {
+	return func(s *Server) {
+		s.users[username] = account{secret: password}
+	}
+}
+
+// WithAPIToken enables security and lets username log in with an API token.
+// Like on Jenkins, API token logins need no crumb.
+func WithAPIToken(username string, token string) Option {
+	return func(s *Server) {
+		s.users[username] = account{secret: token, token: true}
+	}
+}
+
+// WithoutCrumbs disables CSRF protection, /crumbIssuer answers 404.
+func WithoutCrumbs() Option {
+	return func(s *Server) {
+		s.useCrumbs = false
+	}
+}
+
+// WithClock reads the time from now instead of time.Now, e.g. to backdate builds.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// WithAutoStart starts queued builds right away, as if an executor was always free.
+func WithAutoStart() Option {
+	return func(s *Server) {
+		s.autoStart = true
+	}
+}
+
+// WithAutoComplete starts queued builds right away and finishes them with result,
+// as if every build was instantaneous.
+func WithAutoComplete(result string) Option {
+	return func(s *Server) {
+		s.autoStart = true
+		s.autoResult = result
+	}
+}
+
+type account struct {
+	secret string
+	token  bool
+}
+
+// Server is a fake Jenkins controller. Its state is only kept in memory and
+// safe for concurrent use by the HTTP handlers and the test.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	version     string
+	useCrumbs   bool
+	crumb       string
+	autoStart   bool
+	autoResult  string
+	users       map[string]account
+	plugins     []Plugin
+	jobs        map[string]*Job
+	builds      map[string][]*Build
+	queue       []*QueueItem
+	left        []*QueueItem
+	nextQueueID int64
+	nodes       map[string]*Node
+	views       map[string]*View
+	credentials map[string]*credentialStore
+	now         func() time.Time
+}
+
+// NewServer starts a fake Jenkins. Close it when done.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		version:     DefaultVersion,
+		useCrumbs:   true,
+		crumb:       "fake-crumb",
+		users:       map[string]account{},
+		plugins:     append([]Plugin(nil), DefaultPlugins...),
+		jobs:        map[string]*Job{},
+		builds:      map[string][]*Build{},
+		nextQueueID: 1,
+		nodes:       map[string]*Node{},
+		views:       map[string]*View{},
+		credentials: map[string]*credentialStore{},
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.nodes[builtInNode] = &Node{Name: builtInNode, NumExecutors: 2, Label: "built-in", Online: true}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// NewClient returns a client for the server. opts are applied after the
+// client defaults, pass the credentials of a user added with WithUser or WithAPIToken.
+func (s *Server) NewClient(opts ...gojenkins.Option) (*gojenkins.Jenkins, error) {
+	opts = append([]gojenkins.Option{gojenkins.WithHTTPClient(s.Client())}, opts...)
+	return gojenkins.NewClient(s.URL, opts...)
+}
+
+// request is an incoming request split into path segments, without the api/json suffix.
+type request struct {
+	*http.Request
+	segments []string
+	api      bool
+	token    bool
+}
+
+// hasTree reports whether the request selects fields with a tree query. Like on Jenkins,
+// those can reach into nested objects, like the items of folders or the fields of builds.
+func (r *request) hasTree() bool {
+	return r.URL.Query().Get("tree") != ""
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Jenkins", s.version)
+	req := &request{Request: r}
+	for _, segment := range strings.Split(r.URL.Path, "/") {
+		if segment != "" {
+			req.segments = append(req.segments, segment)
+		}
+	}
+	if n := len(req.segments); n >= 2 && req.segments[n-2] == "api" && req.segments[n-1] == "json" {
+		req.segments = req.segments[:n-2]
+		req.api = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.authenticate(w, req) {
+		return
+	}
+	if r.Method == http.MethodPost && s.useCrumbs && !req.token && r.Header.Get(CrumbField) != s.crumb {
+		http.Error(w, "No valid crumb was included in the request", http.StatusForbidden)
+		return
+	}
+	s.route(w, req)
+}
+
+func (s *Server) authenticate(w http.ResponseWriter, req *request) bool {
+	if len(s.users) == 0 {
+		return true
+	}
+	username, secret, ok := req.BasicAuth()
+	if user, found := s.users[username]; ok && found && user.secret == secret {
+		req.token = user.token
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="Jenkins"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	return false
+}
+
+func (s *Server) route(w http.ResponseWriter, req *request) {
+	segments := req.segments
+	if len(segments) == 0 {
+		s.serveRoot(w, req)
+		return
+	}
+	switch segments[0] {
+	case "job":
+		s.serveJob(w, req)
+	case "createItem":
+		s.createItem(w, req, "")
+	case "queue":
+		s.serveQueue(w, req, segments[1:])
+	case "crumbIssuer":
+		s.serveCrumb(w, req)
+	case "credentials":
+		s.serveCredentials(w, req, "", segments[1:])
+	case "computer":
+		s.serveComputer(w, req, segments[1:])
+	case "label":
+		s.serveLabel(w, req, segments[1:])
+	case "view", "createView":
+		s.serveView(w, req, segments)
+	case "pluginManager":
+		s.servePluginManager(w, req, segments[1:])
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) serveRoot(w http.ResponseWriter, req *request) {
+	if req.Method != http.MethodGet || !req.api {
+		notFound(w)
+		return
+	}
+	views := []interface{}{}
+	for _, name := range s.viewNames() {
+		class := allViewClass
+		if view, ok := s.views[name]; ok {
+			class = view.Class
+		}
+		views = append(views, map[string]interface{}{"_class": class, "name": name, "url": s.viewURL(name)})
+	}
+	s.writeJSON(w, req, map[string]interface{}{
+		"_class":          "hudson.model.Hudson",
+		"assignedLabels":  []interface{}{map[string]interface{}{"name": "built-in"}},
+		"description":     nil,
+		"jobs":            s.innerJobs("", req.hasTree()),
+		"mode":            "NORMAL",
+		"nodeDescription": "the Jenkins controller's built-in node",
+		"nodeName":        "",
+		"numExecutors":    s.nodes[builtInNode].NumExecutors,
+		"overallLoad":     map[string]interface{}{},
+		"primaryView":     map[string]interface{}{"_class": allViewClass, "name": allView, "url": s.URL + "/"},
+		"quietingDown":    false,
+		"slaveAgentPort":  50000,
+		"unlabeledLoad":   map[string]interface{}{"_class": "jenkins.model.UnlabeledLoadStatistics"},
+		"useCrumbs":       s.useCrumbs,
+		"useSecurity":     len(s.users) > 0,
+		"views":           views,
+	})
+}
+
+func (s *Server) serveCrumb(w http.ResponseWriter, req *request) {
+	if !s.useCrumbs || !req.api {
+		notFound(w)
+		return
+	}
+	s.writeJSON(w, req, map[string]interface{}{
+		"_class":            "hudson.security.csrf.DefaultCrumbIssuer",
+		"crumb":             s.crumb,
+		"crumbRequestField": CrumbField,
+	})
+}
+
+func (s *Server) servePluginManager(w http.ResponseWriter, req *request, segments []string) {
+	switch {
+	case len(segments) == 0 && req.api && req.Method == http.MethodGet:
+		plugins := []interface{}{}
+		for _, p := range s.plugins {
+			plugins = append(plugins, map[string]interface{}{
+				"active":              !p.Disabled,
+				"backupVersion":       nil,
+				"bundled":             false,
+				"deleted":             false,
+				"dependencies":        []interface{}{},
+				"downgradable":        false,
+				"enabled":             !p.Disabled,
+				"hasUpdate":           false,
+				"longName":            p.LongName,
+				"pinned":              false,
+				"shortName":           p.ShortName,
+				"supportsDynamicLoad": "MAYBE",
+				"url":                 "https://plugins.jenkins.io/" + p.ShortName,
+				"version":             p.Version,
+			})
+		}
+		s.writeJSON(w, req, map[string]interface{}{"_class": "hudson.LocalPluginManager", "plugins": plugins})
+	case len(segments) == 1 && segments[0] == "installNecessaryPlugins" && req.Method == http.MethodPost:
+		root, err := parseXML(req.Body)
+		if err != nil {
+			badRequest(w, err.Error())
+			return
+		}
+		for _, install := range root.children("install") {
+			spec := strings.SplitN(install.attr("plugin"), "@", 2)
+			plugin := Plugin{ShortName: spec[0], LongName: spec[0]}
+			if len(spec) == 2 {
+				plugin.Version = spec[1]
+			}
+			s.removePlugin(plugin.ShortName)
+			s.plugins = append(s.plugins, plugin)
+		}
+	case len(segments) == 3 && segments[0] == "plugin" && segments[2] == "doUninstall" && req.Method == http.MethodPost:
+		if !s.removePlugin(segments[1]) {
+			notFound(w)
+		}
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) hasPlugin(name string) bool {
+	for _, p := range s.plugins {
+		if p.ShortName == name && !p.Disabled {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) removePlugin(name string) bool {
+	for i, p := range s.plugins {
+		if p.ShortName == name {
+			s.plugins = append(s.plugins[:i], s.plugins[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSON answers with v, cut down to the fields selected by the tree query parameter.
+func (s *Server) writeJSON(w http.ResponseWriter, req *request, v interface{}) {
+	if tree := req.URL.Query().Get("tree"); tree != "" {
+		fields, err := parseTree(tree)
+		if err != nil {
+			badRequest(w, err.Error())
+			return
+		}
+		v = filterTree(toGeneric(v), fields)
+	}
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(v)
+}
+
+// toGeneric turns v into the maps and slices produced by encoding/json.
+func toGeneric(v interface{}) interface{} {
+	data, _ := json.Marshal(v)
+	var generic interface{}
+	json.Unmarshal(data, &generic)
+	return generic
+}
+
+// formValue reads a form field from the query string or the request body, including multipart bodies.
+func formValue(req *request, name string) string {
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/") {
+		req.ParseMultipartForm(1 << 20)
+	}
+	return req.FormValue(name)
+}
+
+func queryInt(values url.Values, name string) int64 {
+	n, _ := strconv.ParseInt(values.Get(name), 10, 64)
+	return n
+}
+
+func notFound(w http.ResponseWriter) {
+	http.Error(w, "Not Found", http.StatusNotFound)
+}
+
+// badRequest fails like Jenkins does for invalid form submissions, with the reason in X-Error.
+func badRequest(w http.ResponseWriter, message string) {
+	w.Header().Set("X-Error", message)
+	http.Error(w, message, http.StatusBadRequest)
+}
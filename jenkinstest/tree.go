@@ -0,0 +1,133 @@
+package jenkinstest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// treeField is a field selected by the tree query parameter, e.g. builds[number]{0,5}.
+type treeField struct {
+	name     string
+	children []treeField
+	from     int
+	to       int // -1 means up to the end
+}
+
+// parseTree parses a tree expression like "jobs[name,builds[number]{,3}],color".
+func parseTree(tree string) ([]treeField, error) {
+	fields, rest, err := parseTreeFields(tree)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("invalid tree %q: unexpected %q", tree, rest)
+	}
+	return fields, nil
+}
+
+func parseTreeFields(s string) ([]treeField, string, error) {
+	var fields []treeField
+	for {
+		end := strings.IndexAny(s, ",[]{}")
+		if end < 0 {
+			end = len(s)
+		}
+		field := treeField{name: strings.TrimSpace(s[:end]), to: -1}
+		if field.name == "" {
+			return nil, s, fmt.Errorf("invalid tree: missing field name at %q", s)
+		}
+		s = s[end:]
+		if strings.HasPrefix(s, "[") {
+			children, rest, err := parseTreeFields(s[1:])
+			if err != nil {
+				return nil, s, err
+			}
+			if !strings.HasPrefix(rest, "]") {
+				return nil, s, fmt.Errorf("invalid tree: missing ] at %q", rest)
+			}
+			field.children = children
+			s = rest[1:]
+		}
+		if strings.HasPrefix(s, "{") {
+			end := strings.Index(s, "}")
+			if end < 0 {
+				return nil, s, fmt.Errorf("invalid tree: missing } at %q", s)
+			}
+			if err := field.parseRange(s[1:end]); err != nil {
+				return nil, s, err
+			}
+			s = s[end+1:]
+		}
+		fields = append(fields, field)
+		if !strings.HasPrefix(s, ",") {
+			return fields, s, nil
+		}
+		s = s[1:]
+	}
+}
+
+// parseRange reads {m,n}, {,n}, {m,} or {n}, which selects element n only.
+func (f *treeField) parseRange(r string) error {
+	bounds := strings.SplitN(r, ",", 2)
+	number := func(s string, missing int) (int, error) {
+		if s = strings.TrimSpace(s); s == "" {
+			return missing, nil
+		}
+		return strconv.Atoi(s)
+	}
+	var err error
+	if f.from, err = number(bounds[0], 0); err != nil {
+		return fmt.Errorf("invalid tree range {%s}", r)
+	}
+	if len(bounds) == 1 {
+		f.to = f.from + 1
+		return nil
+	}
+	if f.to, err = number(bounds[1], -1); err != nil {
+		return fmt.Errorf("invalid tree range {%s}", r)
+	}
+	return nil
+}
+
+// filterTree keeps the selected fields of v and the _class of every object.
+func filterTree(v interface{}, fields []treeField) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		filtered := map[string]interface{}{}
+		if class, ok := v["_class"]; ok {
+			filtered["_class"] = class
+		}
+		for _, field := range fields {
+			value, ok := v[field.name]
+			if !ok {
+				continue
+			}
+			if list, ok := value.([]interface{}); ok {
+				value = field.slice(list)
+			}
+			// Like on Jenkins, an object without selected children only keeps its _class.
+			filtered[field.name] = filterTree(value, field.children)
+		}
+		return filtered
+	case []interface{}:
+		filtered := make([]interface{}, len(v))
+		for i, element := range v {
+			filtered[i] = filterTree(element, fields)
+		}
+		return filtered
+	default:
+		return v
+	}
+}
+
+func (f *treeField) slice(list []interface{}) []interface{} {
+	from, to := f.from, f.to
+	if to < 0 || to > len(list) {
+		to = len(list)
+	}
+	if from > to {
+		from = to
+	}
+	return list[from:to]
+}
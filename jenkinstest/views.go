@@ -0,0 +1,112 @@
+package jenkinstest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+const (
+	allView      = "all"
+	allViewClass = "hudson.model.AllView"
+)
+
+// View is a list view. The "all" view always exists and shows every top-level item.
+type View struct {
+	Name  string
+	Class string
+	// Jobs are the full names of the jobs added to the view.
+	Jobs []string
+}
+
+func (s *Server) viewNames() []string {
+	names := []string{allView}
+	for name := range s.views {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+func (s *Server) viewURL(name string) string {
+	if name == allView {
+		return s.URL + "/"
+	}
+	return s.URL + "/view/" + url.PathEscape(name) + "/"
+}
+
+// serveView handles /createView and the requests below /view/NAME.
+func (s *Server) serveView(w http.ResponseWriter, req *request, segments []string) {
+	post := req.Method == http.MethodPost
+	if segments[0] == "createView" {
+		if !post {
+			notFound(w)
+			return
+		}
+		name := formValue(req, "name")
+		if _, exists := s.views[name]; exists || name == "" || name == allView {
+			badRequest(w, "A view already exists with the name "+name)
+			return
+		}
+		s.views[name] = &View{Name: name, Class: formValue(req, "mode")}
+		return
+	}
+	if len(segments) < 2 {
+		notFound(w)
+		return
+	}
+	name := segments[1]
+	view, ok := s.views[name]
+	if name == allView {
+		view = &View{Name: allView, Class: allViewClass}
+		for _, job := range s.children("") {
+			view.Jobs = append(view.Jobs, job.FullName)
+		}
+	} else if !ok {
+		notFound(w)
+		return
+	}
+
+	switch {
+	case len(segments) == 2 && req.api && req.Method == http.MethodGet:
+		jobs := []interface{}{}
+		for _, fullName := range view.Jobs {
+			if job, ok := s.jobs[fullName]; ok {
+				jobs = append(jobs, s.innerJob(job, req.hasTree()))
+			}
+		}
+		s.writeJSON(w, req, map[string]interface{}{
+			"_class":      view.Class,
+			"description": nil,
+			"jobs":        jobs,
+			"name":        view.Name,
+			"property":    []interface{}{},
+			"url":         s.viewURL(view.Name),
+		})
+	case len(segments) == 3 && segments[2] == "addJobToView" && post && name != allView:
+		job := formValue(req, "name")
+		if _, ok := s.jobs[job]; !ok {
+			notFound(w)
+			return
+		}
+		for _, j := range view.Jobs {
+			if j == job {
+				return
+			}
+		}
+		view.Jobs = append(view.Jobs, job)
+	case len(segments) == 3 && segments[2] == "removeJobFromView" && post && name != allView:
+		job := formValue(req, "name")
+		for i, j := range view.Jobs {
+			if j == job {
+				view.Jobs = append(view.Jobs[:i], view.Jobs[i+1:]...)
+				return
+			}
+		}
+		notFound(w)
+	case len(segments) == 3 && segments[2] == "doDelete" && post && name != allView:
+		delete(s.views, name)
+	default:
+		notFound(w)
+	}
+}
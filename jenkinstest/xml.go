@@ -0,0 +1,72 @@
+package jenkinstest
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// xmlNode is a generic XML element, enough to read config.xml files of any kind.
+type xmlNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []xmlNode  `xml:",any"`
+}
+
+var xmlHeader = regexp.MustCompile(`^\s*<\?xml[^>]*\?>`)
+
+// parseXML reads an XML document. Jenkins writes XML 1.1 headers, which encoding/xml rejects.
+func parseXML(r io.Reader) (*xmlNode, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	root := new(xmlNode)
+	if err := xml.Unmarshal(xmlHeader.ReplaceAll(data, nil), root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func (n *xmlNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// children returns the direct child elements called name.
+func (n *xmlNode) children(name string) []xmlNode {
+	var children []xmlNode
+	for _, child := range n.Nodes {
+		if child.XMLName.Local == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// text returns the trimmed text of the first direct child called name.
+func (n *xmlNode) text(name string) string {
+	for _, child := range n.children(name) {
+		return strings.TrimSpace(child.Content)
+	}
+	return ""
+}
+
+// find returns the elements called name at any depth.
+func (n *xmlNode) find(name string) []xmlNode {
+	var found []xmlNode
+	for _, child := range n.Nodes {
+		if child.XMLName.Local == name {
+			found = append(found, child)
+		}
+		found = append(found, child.find(name)...)
+	}
+	return found
+}
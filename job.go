@@ -88,12 +88,7 @@ func (j *Job) parentBase() string {
 	return j.Base[:strings.LastIndex(j.Base, "/job/")]
 }
 
-type History struct {
-	BuildDisplayName string
-	BuildNumber      int
-	BuildStatus      string
-	BuildTimestamp   int64
-}
+type History = utils.History
 
 func (j *Job) GetName() string {
 	return j.Raw.Name
@@ -108,13 +103,7 @@ func (j *Job) GetDetails() *JobResponse {
 }
 
 func (j *Job) GetBuild(ctx context.Context, id int64) (*Build, error) {
-
-	// Support customized server URL,
-	// i.e. Server : https://<domain>/jenkins/job/JOB1
-	// "https://<domain>/jenkins/" is the server URL,
-	// we are expecting jobURL = "job/JOB1"
-	jobURL := strings.Replace(j.Raw.URL, j.Jenkins.Server, "", -1)
-	build := Build{Jenkins: j.Jenkins, Job: j, Raw: new(BuildResponse), Depth: 1, Base: jobURL + "/" + strconv.FormatInt(id, 10)}
+	build := Build{Jenkins: j.Jenkins, Job: j, Raw: new(BuildResponse), Depth: 1, Base: j.Base + "/" + strconv.FormatInt(id, 10)}
 	status, err := build.Poll(ctx)
 	if err != nil {
 		return nil, err
@@ -122,7 +111,7 @@ func (j *Job) GetBuild(ctx context.Context, id int64) (*Build, error) {
 	if status == 200 {
 		return &build, nil
 	}
-	return nil, errors.New(strconv.Itoa(status))
+	return nil, unexpectedStatus("GET", build.Base, status)
 }
 
 func (j *Job) getBuildByType(ctx context.Context, buildType string) (*Build, error) {
@@ -153,7 +142,7 @@ func (j *Job) getBuildByType(ctx context.Context, buildType string) (*Build, err
 	if status == 200 {
 		return &build, nil
 	}
-	return nil, errors.New(strconv.Itoa(status))
+	return nil, unexpectedStatus("GET", build.Base, status)
 }
 
 func (j *Job) GetLastSuccessfulBuild(ctx context.Context) (*Build, error) {
@@ -185,7 +174,8 @@ func (j *Job) GetBuildsFields(ctx context.Context, fields []string, custom inter
 		return fmt.Errorf("one or more field value needs to be specified")
 	}
 	// limit overhead using builds instead of allBuilds, which returns the last 100 build
-	_, err := j.Jenkins.Requester.GetJSON(ctx, j.Base, &custom, map[string]string{"tree": "builds[" + strings.Join(fields, ",") + "]"})
+	tree := NewTree(Field("builds", Fields(fields...)...))
+	_, err := j.Jenkins.Requester.GetJSON(ctx, j.Base, &custom, tree.query())
 	if err != nil {
 		return err
 	}
@@ -197,7 +187,8 @@ func (j *Job) GetAllBuildIds(ctx context.Context) ([]JobBuild, error) {
 	var buildsResp struct {
 		Builds []JobBuild `json:"allBuilds"`
 	}
-	_, err := j.Jenkins.Requester.GetJSON(ctx, j.Base, &buildsResp, map[string]string{"tree": "allBuilds[number,url]"})
+	tree := NewTree(Field("allBuilds", Fields("number", "url")...))
+	_, err := j.Jenkins.Requester.GetJSON(ctx, j.Base, &buildsResp, tree.query())
 	if err != nil {
 		return nil, err
 	}
@@ -241,7 +232,7 @@ func (j *Job) GetDownstreamJobs(ctx context.Context) ([]*Job, error) {
 }
 
 func (j *Job) GetInnerJob(ctx context.Context, id string) (*Job, error) {
-	job := Job{Jenkins: j.Jenkins, Raw: new(JobResponse), Base: j.Base + "/job/" + id}
+	job := Job{Jenkins: j.Jenkins, Raw: new(JobResponse), Base: j.Base + JobPath(id)}
 	status, err := job.Poll(ctx)
 	if err != nil {
 		return nil, err
@@ -249,7 +240,7 @@ func (j *Job) GetInnerJob(ctx context.Context, id string) (*Job, error) {
 	if status == 200 {
 		return &job, nil
 	}
-	return nil, errors.New(strconv.Itoa(status))
+	return nil, unexpectedStatus("GET", job.Base, status)
 }
 
 func (j *Job) GetInnerJobs(ctx context.Context) ([]*Job, error) {
@@ -270,7 +261,7 @@ func (j *Job) Enable(ctx context.Context) (bool, error) {
 		return false, err
 	}
 	if resp.StatusCode != 200 {
-		return false, errors.New(strconv.Itoa(resp.StatusCode))
+		return false, newAPIError(resp)
 	}
 	return true, nil
 }
@@ -281,7 +272,7 @@ func (j *Job) Disable(ctx context.Context) (bool, error) {
 		return false, err
 	}
 	if resp.StatusCode != 200 {
-		return false, errors.New(strconv.Itoa(resp.StatusCode))
+		return false, newAPIError(resp)
 	}
 	return true, nil
 }
@@ -292,7 +283,7 @@ func (j *Job) Delete(ctx context.Context) (bool, error) {
 		return false, err
 	}
 	if resp.StatusCode != 200 {
-		return false, errors.New(strconv.Itoa(resp.StatusCode))
+		return false, newAPIError(resp)
 	}
 	return true, nil
 }
@@ -310,7 +301,7 @@ func (j *Job) Rename(ctx context.Context, name string) (bool, error) {
 func (j *Job) Create(ctx context.Context, config string, qr ...interface{}) (*Job, error) {
 	var querystring map[string]string
 	if len(qr) > 0 {
-		querystring = qr[0].(map[string]string)
+		querystring, _ = qr[0].(map[string]string)
 	}
 	resp, err := j.Jenkins.Requester.PostXML(ctx, j.parentBase()+"/createItem", config, j.Raw, querystring)
 	if err != nil {
@@ -320,7 +311,7 @@ func (j *Job) Create(ctx context.Context, config string, qr ...interface{}) (*Jo
 		j.Poll(ctx)
 		return j, nil
 	}
-	return nil, errors.New(strconv.Itoa(resp.StatusCode))
+	return nil, newAPIError(resp)
 }
 
 func (j *Job) Copy(ctx context.Context, destinationName string) (*Job, error) {
@@ -330,14 +321,14 @@ func (j *Job) Copy(ctx context.Context, destinationName string) (*Job, error) {
 		return nil, err
 	}
 	if resp.StatusCode == 200 {
-		newJob := &Job{Jenkins: j.Jenkins, Raw: new(JobResponse), Base: "/job/" + destinationName}
+		newJob := &Job{Jenkins: j.Jenkins, Raw: new(JobResponse), Base: j.parentBase() + JobPath(destinationName)}
 		_, err := newJob.Poll(ctx)
 		if err != nil {
 			return nil, err
 		}
 		return newJob, nil
 	}
-	return nil, errors.New(strconv.Itoa(resp.StatusCode))
+	return nil, newAPIError(resp)
 }
 
 func (j *Job) UpdateConfig(ctx context.Context, config string) error {
@@ -352,7 +343,7 @@ func (j *Job) UpdateConfig(ctx context.Context, config string) error {
 		j.Poll(ctx)
 		return nil
 	}
-	return errors.New(strconv.Itoa(resp.StatusCode))
+	return newAPIError(resp)
 
 }
 
@@ -412,7 +403,7 @@ func (j *Job) InvokeSimple(ctx context.Context, params map[string]string) (int64
 		return 0, err
 	}
 	if isQueued {
-		Error.Printf("%s is already running", j.GetName())
+		j.Jenkins.Requester.logger().Warn("job is already queued, not invoking it", "job", j.GetName())
 		return 0, nil
 	}
 
@@ -461,7 +452,7 @@ func (j *Job) Invoke(ctx context.Context, files []string, skipIfRunning bool, pa
 		return false, err
 	}
 	if isQueued {
-		Error.Printf("%s is already running", j.GetName())
+		j.Jenkins.Requester.logger().Warn("job is already queued, not invoking it", "job", j.GetName())
 		return false, nil
 	}
 	isRunning, err := j.IsRunning(ctx)
@@ -500,7 +491,7 @@ func (j *Job) Invoke(ctx context.Context, files []string, skipIfRunning bool, pa
 	if resp.StatusCode == 200 || resp.StatusCode == 201 {
 		return true, nil
 	}
-	return false, errors.New(strconv.Itoa(resp.StatusCode))
+	return false, newAPIError(resp)
 }
 
 func (j *Job) Poll(ctx context.Context) (int, error) {
@@ -511,6 +502,17 @@ func (j *Job) Poll(ctx context.Context) (int, error) {
 	return response.StatusCode, nil
 }
 
+// PollFields fetches only the fields selected by tree. Fields left out are zero in Raw.
+func (j *Job) PollFields(ctx context.Context, tree Tree) (int, error) {
+	raw := new(JobResponse)
+	response, err := j.Jenkins.Requester.GetJSON(ctx, j.Base, raw, tree.query())
+	if err != nil {
+		return 0, err
+	}
+	j.Raw = raw
+	return response.StatusCode, nil
+}
+
 func (j *Job) History(ctx context.Context) ([]*History, error) {
 	var s string
 	_, err := j.Jenkins.Requester.Get(ctx, j.Base+"/buildHistory/ajax", &s, nil)
@@ -522,7 +524,13 @@ func (j *Job) History(ctx context.Context) ([]*History, error) {
 }
 
 func (pr *PipelineRun) ProceedInput(ctx context.Context) (bool, error) {
-	actions, _ := pr.GetPendingInputActions(ctx)
+	actions, err := pr.GetPendingInputActions(ctx)
+	if err != nil {
+		return false, err
+	}
+	if len(actions) == 0 {
+		return false, errors.New("no pending input action")
+	}
 	data := url.Values{}
 	data.Set("inputId", actions[0].ID)
 	params := make(map[string]string)
@@ -535,13 +543,19 @@ func (pr *PipelineRun) ProceedInput(ctx context.Context) (bool, error) {
 		return false, err
 	}
 	if resp.StatusCode != 200 {
-		return false, errors.New(strconv.Itoa(resp.StatusCode))
+		return false, newAPIError(resp)
 	}
 	return true, nil
 }
 
 func (pr *PipelineRun) AbortInput(ctx context.Context) (bool, error) {
-	actions, _ := pr.GetPendingInputActions(ctx)
+	actions, err := pr.GetPendingInputActions(ctx)
+	if err != nil {
+		return false, err
+	}
+	if len(actions) == 0 {
+		return false, errors.New("no pending input action")
+	}
 	data := url.Values{}
 	params := make(map[string]string)
 	data.Set("json", utils.MakeJson(params))
@@ -553,7 +567,7 @@ func (pr *PipelineRun) AbortInput(ctx context.Context) (bool, error) {
 		return false, err
 	}
 	if resp.StatusCode != 200 {
-		return false, errors.New(strconv.Itoa(resp.StatusCode))
+		return false, newAPIError(resp)
 	}
 	return true, nil
 }
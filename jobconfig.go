@@ -0,0 +1,509 @@
+package gojenkins
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Classes of the jobs and objects described by typed job configs, and of folders.
+const (
+	ClassFreeStyleProject     = "hudson.model.FreeStyleProject"
+	ClassWorkflowJob          = "org.jenkinsci.plugins.workflow.job.WorkflowJob"
+	ClassFolder               = "com.cloudbees.hudson.plugins.folder.Folder"
+	ClassNullSCM              = "hudson.scm.NullSCM"
+	ClassGitSCM               = "hudson.plugins.git.GitSCM"
+	ClassLogRotator           = "hudson.tasks.LogRotator"
+	ClassCpsFlowDefinition    = "org.jenkinsci.plugins.workflow.cps.CpsFlowDefinition"
+	ClassCpsScmFlowDefinition = "org.jenkinsci.plugins.workflow.cps.CpsScmFlowDefinition"
+)
+
+// Element names of parameter definitions and build steps.
+const (
+	StringParameter   = "hudson.model.StringParameterDefinition"
+	TextParameter     = "hudson.model.TextParameterDefinition"
+	BooleanParameter  = "hudson.model.BooleanParameterDefinition"
+	ChoiceParameter   = "hudson.model.ChoiceParameterDefinition"
+	PasswordParameter = "hudson.model.PasswordParameterDefinition"
+	ShellStep         = "hudson.tasks.Shell"
+	BatchStep         = "hudson.tasks.BatchFile"
+)
+
+// JobConfig is a typed config.xml, a *FreeStyleJobConfig or a *PipelineJobConfig.
+// Elements the types do not know about, e.g. those of plugins, are kept in their
+// Other fields and written back unchanged. A config read with ParseJobConfig is
+// written back in the order of the document it was read from.
+type JobConfig interface {
+	parsedFrom() string
+}
+
+// RawElement is an XML element kept as is.
+type RawElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   string     `xml:",innerxml"`
+}
+
+// FreeStyleJobConfig is the config.xml of a hudson.model.FreeStyleProject.
+type FreeStyleJobConfig struct {
+	XMLName     xml.Name      `xml:"project"`
+	Attrs       []xml.Attr    `xml:",any,attr"`
+	Description string        `xml:"description"`
+	Properties  JobProperties `xml:"properties"`
+	SCM         *SCM          `xml:"scm,omitempty"`
+	Disabled    bool          `xml:"disabled"`
+	Triggers    Triggers      `xml:"triggers"`
+	Builders    BuildSteps    `xml:"builders"`
+	Other       []RawElement  `xml:",any"`
+
+	source string
+}
+
+// PipelineJobConfig is the config.xml of an org.jenkinsci.plugins.workflow.job.WorkflowJob.
+// Its triggers are held by Properties.PipelineTriggers.
+type PipelineJobConfig struct {
+	XMLName     xml.Name            `xml:"flow-definition"`
+	Attrs       []xml.Attr          `xml:",any,attr"`
+	Description string              `xml:"description"`
+	Properties  JobProperties       `xml:"properties"`
+	Definition  *PipelineDefinition `xml:"definition,omitempty"`
+	Disabled    bool                `xml:"disabled"`
+	Other       []RawElement        `xml:",any"`
+
+	source string
+}
+
+func (c *FreeStyleJobConfig) parsedFrom() string { return c.source }
+func (c *PipelineJobConfig) parsedFrom() string  { return c.source }
+
+// JobProperties are the properties of a job.
+type JobProperties struct {
+	Parameters       *ParametersProperty       `xml:"hudson.model.ParametersDefinitionProperty,omitempty"`
+	BuildDiscarder   *BuildDiscarderProperty   `xml:"jenkins.model.BuildDiscarderProperty,omitempty"`
+	PipelineTriggers *PipelineTriggersProperty `xml:"org.jenkinsci.plugins.workflow.job.properties.PipelineTriggersJobProperty,omitempty"`
+	Other            []RawElement              `xml:",any"`
+}
+
+// ParametersProperty holds the parameter definitions of a parameterized job.
+type ParametersProperty struct {
+	Definitions struct {
+		Parameters []ParameterConfig `xml:",any"`
+	} `xml:"parameterDefinitions"`
+}
+
+// ParameterConfig is a parameter definition, named after its class, e.g. StringParameter.
+type ParameterConfig struct {
+	XMLName      xml.Name
+	Name         string       `xml:"name"`
+	Description  string       `xml:"description,omitempty"`
+	DefaultValue string       `xml:"defaultValue,omitempty"`
+	Choices      *Choices     `xml:"choices,omitempty"`
+	Other        []RawElement `xml:",any"`
+}
+
+// Choices are the choices of a ChoiceParameter.
+type Choices struct {
+	Class string `xml:"class,attr,omitempty"`
+	Array struct {
+		Class  string   `xml:"class,attr,omitempty"`
+		Values []string `xml:"string"`
+	} `xml:"a"`
+}
+
+// NewParameter returns a parameter definition of class, e.g. StringParameter.
+func NewParameter(class string, name string, defaultValue string, description string) ParameterConfig {
+	return ParameterConfig{XMLName: xml.Name{Local: class}, Name: name, DefaultValue: defaultValue, Description: description}
+}
+
+// NewChoiceParameter returns a ChoiceParameter, the first choice is the default.
+func NewChoiceParameter(name string, description string, choices ...string) ParameterConfig {
+	p := NewParameter(ChoiceParameter, name, "", description)
+	p.Choices = &Choices{Class: "java.util.Arrays$ArrayList"}
+	p.Choices.Array.Class = "string-array"
+	p.Choices.Array.Values = choices
+	return p
+}
+
+// AddParameter adds a parameter definition to the job.
+func (p *JobProperties) AddParameter(parameter ParameterConfig) {
+	if p.Parameters == nil {
+		p.Parameters = &ParametersProperty{}
+	}
+	p.Parameters.Definitions.Parameters = append(p.Parameters.Definitions.Parameters, parameter)
+}
+
+// BuildDiscarderProperty discards old builds.
+type BuildDiscarderProperty struct {
+	Strategy LogRotator `xml:"strategy"`
+}
+
+// LogRotator keeps builds and artifacts for a number of days or builds, -1 means no limit.
+type LogRotator struct {
+	Class              string `xml:"class,attr"`
+	DaysToKeep         int    `xml:"daysToKeep"`
+	NumToKeep          int    `xml:"numToKeep"`
+	ArtifactDaysToKeep int    `xml:"artifactDaysToKeep"`
+	ArtifactNumToKeep  int    `xml:"artifactNumToKeep"`
+}
+
+// NewBuildDiscarder keeps builds for days and at most num builds, -1 means no limit.
+func NewBuildDiscarder(days int, num int) *BuildDiscarderProperty {
+	return &BuildDiscarderProperty{Strategy: LogRotator{
+		Class:              ClassLogRotator,
+		DaysToKeep:         days,
+		NumToKeep:          num,
+		ArtifactDaysToKeep: -1,
+		ArtifactNumToKeep:  -1,
+	}}
+}
+
+// PipelineTriggersProperty holds the triggers of a pipeline job.
+type PipelineTriggersProperty struct {
+	Triggers Triggers `xml:"triggers"`
+}
+
+// Triggers start builds on a schedule, on SCM changes or by plugin triggers kept in Other.
+type Triggers struct {
+	Attrs []xml.Attr    `xml:",any,attr"`
+	Timer *TimerTrigger `xml:"hudson.triggers.TimerTrigger,omitempty"`
+	SCM   *SCMTrigger   `xml:"hudson.triggers.SCMTrigger,omitempty"`
+	Other []RawElement  `xml:",any"`
+}
+
+// TimerTrigger builds periodically, Spec is a cron expression like "H 2 * * *".
+type TimerTrigger struct {
+	Spec string `xml:"spec"`
+}
+
+// SCMTrigger polls the SCM with the cron expression Spec.
+type SCMTrigger struct {
+	Spec                  string `xml:"spec"`
+	IgnorePostCommitHooks bool   `xml:"ignorePostCommitHooks"`
+}
+
+// SCM is the source code checkout of a job, ClassNullSCM for none.
+// The fields besides Class and Plugin apply to ClassGitSCM.
+type SCM struct {
+	Class         string       `xml:"class,attr"`
+	Plugin        string       `xml:"plugin,attr,omitempty"`
+	ConfigVersion string       `xml:"configVersion,omitempty"`
+	Remotes       []GitRemote  `xml:"userRemoteConfigs>hudson.plugins.git.UserRemoteConfig"`
+	Branches      []GitBranch  `xml:"branches>hudson.plugins.git.BranchSpec"`
+	Other         []RawElement `xml:",any"`
+}
+
+// MarshalXML leaves the Git elements out of the SCMs that have none,
+// encoding/xml would write their empty parents.
+func (s SCM) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plainSCM SCM
+	if len(s.Remotes) > 0 || len(s.Branches) > 0 {
+		return e.EncodeElement(plainSCM(s), start)
+	}
+	return e.EncodeElement(struct {
+		Class         string       `xml:"class,attr"`
+		Plugin        string       `xml:"plugin,attr,omitempty"`
+		ConfigVersion string       `xml:"configVersion,omitempty"`
+		Other         []RawElement `xml:",any"`
+	}{s.Class, s.Plugin, s.ConfigVersion, s.Other}, start)
+}
+
+// GitRemote is a repository checked out by a GitSCM.
+type GitRemote struct {
+	URL           string `xml:"url"`
+	CredentialsID string `xml:"credentialsId,omitempty"`
+}
+
+// GitBranch is a branch specifier like */main.
+type GitBranch struct {
+	Name string `xml:"name"`
+}
+
+// NewGitSCM checks out the branches of the repository at url, with the credential credentialsID if not empty.
+func NewGitSCM(url string, credentialsID string, branches ...string) *SCM {
+	scm := &SCM{Class: ClassGitSCM, Plugin: "git", ConfigVersion: "2", Remotes: []GitRemote{{URL: url, CredentialsID: credentialsID}}}
+	for _, branch := range branches {
+		scm.Branches = append(scm.Branches, GitBranch{Name: branch})
+	}
+	return scm
+}
+
+// BuildSteps are the build steps of a freestyle job, in order.
+type BuildSteps struct {
+	Steps []BuildStep `xml:",any"`
+}
+
+// BuildStep is a build step, named after its class, e.g. ShellStep. Command applies to
+// ShellStep and BatchStep, the elements of other steps are kept in Other.
+type BuildStep struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr   `xml:",any,attr"`
+	Command string       `xml:"command,omitempty"`
+	Other   []RawElement `xml:",any"`
+}
+
+// AddShell adds a step running command with the shell.
+func (b *BuildSteps) AddShell(command string) {
+	b.Steps = append(b.Steps, BuildStep{XMLName: xml.Name{Local: ShellStep}, Command: command})
+}
+
+// PipelineDefinition is the Pipeline script of a job: inline with ClassCpsFlowDefinition,
+// or read from ScriptPath in SCM with ClassCpsScmFlowDefinition.
+type PipelineDefinition struct {
+	Class       string       `xml:"class,attr"`
+	Plugin      string       `xml:"plugin,attr,omitempty"`
+	Script      string       `xml:"script,omitempty"`
+	Sandbox     bool         `xml:"sandbox,omitempty"`
+	SCM         *SCM         `xml:"scm,omitempty"`
+	ScriptPath  string       `xml:"scriptPath,omitempty"`
+	Lightweight bool         `xml:"lightweight,omitempty"`
+	Other       []RawElement `xml:",any"`
+}
+
+// NewInlinePipeline runs script in the Groovy sandbox.
+func NewInlinePipeline(script string) *PipelineDefinition {
+	return &PipelineDefinition{Class: ClassCpsFlowDefinition, Plugin: "workflow-cps", Script: script, Sandbox: true}
+}
+
+// NewSCMPipeline runs the Jenkinsfile at scriptPath in scm.
+func NewSCMPipeline(scm *SCM, scriptPath string) *PipelineDefinition {
+	return &PipelineDefinition{Class: ClassCpsScmFlowDefinition, Plugin: "workflow-cps", SCM: scm, ScriptPath: scriptPath, Lightweight: true}
+}
+
+// xmlDeclaration matches the XML declaration, which encoding/xml rejects for version 1.1.
+var xmlDeclaration = regexp.MustCompile(`^\s*<\?xml[^>]*\?>`)
+
+// ParseJobConfig parses the config.xml of a freestyle or pipeline job.
+func ParseJobConfig(data string) (JobConfig, error) {
+	body := []byte(xmlDeclaration.ReplaceAllString(data, ""))
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	var root string
+	for root == "" {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("gojenkins: invalid job config: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			root = start.Name.Local
+		}
+	}
+
+	var config JobConfig
+	switch root {
+	case "project":
+		config = &FreeStyleJobConfig{source: data}
+	case "flow-definition":
+		config = &PipelineJobConfig{source: data}
+	default:
+		return nil, fmt.Errorf("gojenkins: unsupported job config <%s>, only freestyle and pipeline jobs are typed", root)
+	}
+	if err := xml.Unmarshal(body, config); err != nil {
+		return nil, fmt.Errorf("gojenkins: invalid job config: %w", err)
+	}
+	return config, nil
+}
+
+// MarshalJobConfig encodes config as config.xml. A config read with ParseJobConfig
+// keeps the element order, formatting and declaration of the document it was read
+// from, only the elements that changed are rewritten.
+func MarshalJobConfig(config JobConfig) (string, error) {
+	data, err := xml.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	encoded := string(jenkinsText(data))
+	if source := config.parsedFrom(); source != "" {
+		return mergeJobConfig(source, encoded)
+	}
+	return "<?xml version='1.1' encoding='UTF-8'?>\n" + encoded + "\n", nil
+}
+
+// mergeJobConfig writes encoded, a freshly encoded job config, over source, the
+// document the config was parsed from.
+func mergeJobConfig(source string, encoded string) (string, error) {
+	original, err := parseConfigTree(source)
+	if err != nil {
+		return "", err
+	}
+	updated, err := parseConfigTree(encoded)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	out.WriteString(source[:original.start])
+	mergeElement(&out, source, original, encoded, updated)
+	out.WriteString(source[original.end:])
+	return out.String(), nil
+}
+
+// mergeElement writes the element updated of encoded in place of original of source.
+// An unchanged element is copied from source. The children of a changed element
+// keep their order in source, removed ones are dropped and new ones are inserted
+// after the child preceding them in encoded.
+func mergeElement(out *strings.Builder, source string, original *configNode, encoded string, updated *configNode) {
+	if canonicalXML(source[original.start:original.end]) == canonicalXML(encoded[updated.start:updated.end]) {
+		out.WriteString(source[original.start:original.end])
+		return
+	}
+	if len(original.children) == 0 || len(updated.children) == 0 {
+		out.WriteString(encoded[updated.start:updated.end])
+		return
+	}
+	if canonicalAttrs(original.attrs) == canonicalAttrs(updated.attrs) {
+		out.WriteString(source[original.start:original.contentStart])
+	} else {
+		out.WriteString(encoded[updated.start:updated.contentStart])
+	}
+
+	// Children are matched by name and position among the children of that name.
+	byName := map[string][]*configNode{}
+	position := map[*configNode]int{}
+	for i, child := range updated.children {
+		byName[child.name] = append(byName[child.name], child)
+		position[child] = i
+	}
+	matches := map[*configNode]*configNode{}
+	matched := map[*configNode]bool{}
+	for _, child := range original.children {
+		if candidates := byName[child.name]; len(candidates) > 0 {
+			matches[child] = candidates[0]
+			matched[candidates[0]] = true
+			byName[child.name] = candidates[1:]
+		}
+	}
+
+	indent := original.children[0].indent(source)
+	next := 0
+	insertBefore := func(end int) {
+		for ; next < end; next++ {
+			if child := updated.children[next]; !matched[child] {
+				out.WriteString("\n" + indent + encoded[child.start:child.end])
+			}
+		}
+	}
+	previous := original.contentStart
+	for _, child := range original.children {
+		if match, ok := matches[child]; ok {
+			insertBefore(position[match])
+			out.WriteString(source[previous:child.start])
+			mergeElement(out, source, child, encoded, match)
+		}
+		previous = child.end
+	}
+	insertBefore(len(updated.children))
+	out.WriteString(source[previous:original.end])
+}
+
+// canonicalXML returns the elements, attributes and text of fragment in a form
+// that ignores formatting: whitespace between elements, the order of attributes,
+// character references, self-closing tags and comments.
+func canonicalXML(fragment string) string {
+	var out strings.Builder
+	decoder := xml.NewDecoder(strings.NewReader(fragment))
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return out.String()
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			out.WriteString("<" + qualifiedName(token.Name) + canonicalAttrs(token.Attr) + ">")
+		case xml.EndElement:
+			out.WriteString("</" + qualifiedName(token.Name) + ">")
+		case xml.CharData:
+			if strings.TrimSpace(string(token)) != "" {
+				out.WriteString(strconv.Quote(string(token)))
+			}
+		}
+	}
+}
+
+func canonicalAttrs(attrs []xml.Attr) string {
+	pairs := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		pairs = append(pairs, " "+qualifiedName(attr.Name)+"="+strconv.Quote(attr.Value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "")
+}
+
+// textReferences are the character references encoding/xml writes in text and Jenkins does not.
+var textReferences = strings.NewReplacer("&#xA;", "\n", "&#x9;", "\t", "&#34;", `"`, "&#39;", "'")
+
+// jenkinsText writes newlines, tabs and quotes in text as is, like Jenkins does.
+// Tags, comments and CDATA sections are copied unchanged.
+func jenkinsText(data []byte) []byte {
+	var out bytes.Buffer
+	for len(data) > 0 {
+		if data[0] != '<' {
+			end := bytes.IndexByte(data, '<')
+			if end < 0 {
+				end = len(data)
+			}
+			out.WriteString(textReferences.Replace(string(data[:end])))
+			data = data[end:]
+			continue
+		}
+		end := markupEnd(data)
+		out.Write(data[:end])
+		data = data[end:]
+	}
+	return out.Bytes()
+}
+
+// markupEnd returns the length of the tag, comment or CDATA section data starts with.
+func markupEnd(data []byte) int {
+	for _, delimiters := range [][2]string{{"<![CDATA[", "]]>"}, {"<!--", "-->"}} {
+		if bytes.HasPrefix(data, []byte(delimiters[0])) {
+			if end := bytes.Index(data, []byte(delimiters[1])); end >= 0 {
+				return end + len(delimiters[1])
+			}
+			return len(data)
+		}
+	}
+	var quote byte
+	for i := 1; i < len(data); i++ {
+		switch {
+		case quote != 0:
+			if data[i] == quote {
+				quote = 0
+			}
+		case data[i] == '"' || data[i] == '\'':
+			quote = data[i]
+		case data[i] == '>':
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// GetTypedConfig fetches the config.xml of a freestyle or pipeline job as a typed config.
+func (j *Job) GetTypedConfig(ctx context.Context) (JobConfig, error) {
+	data, err := j.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJobConfig(data)
+}
+
+// UpdateTypedConfig replaces the config.xml of the job with config.
+func (j *Job) UpdateTypedConfig(ctx context.Context, config JobConfig) error {
+	data, err := MarshalJobConfig(config)
+	if err != nil {
+		return err
+	}
+	return j.UpdateConfig(ctx, data)
+}
+
+// CreateJobFromConfig creates a job from a typed config, inside opts.Folders if set.
+func (j *Jenkins) CreateJobFromConfig(ctx context.Context, config JobConfig, opts CreateJobOptions) (*Job, error) {
+	data, err := MarshalJobConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return j.CreateJobWithOptions(ctx, data, opts)
+}
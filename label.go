@@ -48,3 +48,14 @@ func (l *Label) Poll(ctx context.Context) (int, error) {
 	}
 	return response.StatusCode, nil
 }
+
+// PollFields fetches only the fields selected by tree. Fields left out are zero in Raw.
+func (l *Label) PollFields(ctx context.Context, tree Tree) (int, error) {
+	raw := new(LabelResponse)
+	response, err := l.Jenkins.Requester.GetJSON(ctx, l.Base, raw, tree.query())
+	if err != nil {
+		return 0, err
+	}
+	l.Raw = raw
+	return response.StatusCode, nil
+}
@@ -0,0 +1,212 @@
+package gojenkins
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Limit restricts the load one class of requests, reads or writes, puts on Jenkins.
+type Limit struct {
+	// Rate is the sustained number of requests per second, zero means unlimited.
+	Rate float64
+	// Burst is the number of requests which can be sent at once before Rate applies.
+	// It is at least 1.
+	Burst int
+	// MaxInFlight caps the number of concurrent requests, zero means unlimited.
+	MaxInFlight int
+}
+
+// LimitStats describes the waiting caused by a Limit.
+type LimitStats struct {
+	// Requests counts the requests sent through the limit, once however many attempts they take.
+	Requests int64
+	// Delayed counts the waits for a token or a free slot. A request waiting for
+	// both, or retried, can be delayed more than once.
+	Delayed int64
+	// Canceled counts the waits cut short because the context of the request was done.
+	Canceled  int64
+	TotalWait time.Duration
+	MaxWait   time.Duration
+	InFlight  int
+}
+
+// LimiterStats holds the statistics of the read and write limits.
+type LimiterStats struct {
+	Read  LimitStats
+	Write LimitStats
+}
+
+// SetLimits limits GET and HEAD requests to read and all other requests to write.
+// A zero Limit removes the limit. It must not be called while requests are in flight.
+func (r *Requester) SetLimits(read Limit, write Limit) {
+	r.readLimiter = newLimiter(read)
+	r.writeLimiter = newLimiter(write)
+}
+
+// LimiterStats returns the statistics of the limits set with SetLimits.
+func (r *Requester) LimiterStats() LimiterStats {
+	return LimiterStats{Read: r.readLimiter.snapshot(), Write: r.writeLimiter.snapshot()}
+}
+
+func (r *Requester) limiter(method string) *limiter {
+	if method == http.MethodGet || method == http.MethodHead {
+		return r.readLimiter
+	}
+	return r.writeLimiter
+}
+
+// limiter combines a token bucket for the rate with a semaphore for the requests in flight.
+type limiter struct {
+	bucket *tokenBucket
+	slots  chan struct{}
+
+	mu    sync.Mutex
+	stats LimitStats
+}
+
+func newLimiter(l Limit) *limiter {
+	if l.Rate <= 0 && l.MaxInFlight <= 0 {
+		return nil
+	}
+	lim := &limiter{}
+	if l.Rate > 0 {
+		lim.bucket = newTokenBucket(l.Rate, l.Burst)
+	}
+	if l.MaxInFlight > 0 {
+		lim.slots = make(chan struct{}, l.MaxInFlight)
+	}
+	return lim
+}
+
+// acquire takes a slot for a request, the returned function gives it back.
+// Every request is counted here, once, whatever its number of attempts.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			start := time.Now()
+			select {
+			case l.slots <- struct{}{}:
+			case <-ctx.Done():
+				l.record(0, true)
+				return nil, ctx.Err()
+			}
+			l.record(time.Since(start), false)
+		}
+	}
+
+	l.mu.Lock()
+	l.stats.Requests++
+	if l.slots != nil {
+		l.stats.InFlight++
+	}
+	l.mu.Unlock()
+	if l.slots == nil {
+		return func() {}, nil
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.stats.InFlight--
+			l.mu.Unlock()
+			<-l.slots
+		})
+	}, nil
+}
+
+// wait blocks until the rate allows one more attempt of a request to be sent.
+func (l *limiter) wait(ctx context.Context) error {
+	if l == nil || l.bucket == nil {
+		return nil
+	}
+	delay := l.bucket.reserve(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		l.record(delay, false)
+		return nil
+	case <-ctx.Done():
+		l.bucket.cancel()
+		l.record(0, true)
+		return ctx.Err()
+	}
+}
+
+// record counts a wait for a slot or a token, which lasted wait unless it was canceled.
+func (l *limiter) record(wait time.Duration, canceled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if canceled {
+		l.stats.Canceled++
+		return
+	}
+	l.stats.Delayed++
+	l.stats.TotalWait += wait
+	if wait > l.stats.MaxWait {
+		l.stats.MaxWait = wait
+	}
+}
+
+func (l *limiter) snapshot() LimitStats {
+	if l == nil {
+		return LimitStats{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// tokenBucket hands out rate tokens per second, storing up to burst of them.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// reserve takes a token and returns how long the caller has to wait until it is valid.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token which was not used.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
@@ -0,0 +1,108 @@
+package gojenkins
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger receives the log messages of a client. keysAndValues holds alternating
+// field names and values, such as "job", "build", "endpoint" or "status".
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// nopLogger discards everything, it is used when no Logger is set.
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, keysAndValues ...interface{}) {}
+func (nopLogger) Info(msg string, keysAndValues ...interface{})  {}
+func (nopLogger) Warn(msg string, keysAndValues ...interface{})  {}
+func (nopLogger) Error(msg string, keysAndValues ...interface{}) {}
+
+// LogLevel is the severity of a log message, for NewStdLoggerLevel.
+type LogLevel int
+
+// Log levels from the most to the least verbose.
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogWarn:
+		return "WARN"
+	}
+	return "ERROR"
+}
+
+// stdLogger writes to a *log.Logger, with the fields formatted as key=value.
+type stdLogger struct {
+	l   *log.Logger
+	min LogLevel
+}
+
+// NewStdLogger returns a Logger printing messages of LogInfo and above through l, e.g.
+//
+//	INFO retrying request method=GET endpoint=/job/app/api/json attempt=1
+func NewStdLogger(l *log.Logger) Logger {
+	return NewStdLoggerLevel(l, LogInfo)
+}
+
+// NewStdLoggerLevel is like NewStdLogger but prints the messages of min and above,
+// LogDebug includes a line for every request.
+func NewStdLoggerLevel(l *log.Logger, min LogLevel) Logger {
+	return stdLogger{l: l, min: min}
+}
+
+func (s stdLogger) Debug(msg string, keysAndValues ...interface{}) {
+	s.print(LogDebug, msg, keysAndValues)
+}
+
+func (s stdLogger) Info(msg string, keysAndValues ...interface{}) {
+	s.print(LogInfo, msg, keysAndValues)
+}
+
+func (s stdLogger) Warn(msg string, keysAndValues ...interface{}) {
+	s.print(LogWarn, msg, keysAndValues)
+}
+
+func (s stdLogger) Error(msg string, keysAndValues ...interface{}) {
+	s.print(LogError, msg, keysAndValues)
+}
+
+func (s stdLogger) print(level LogLevel, msg string, keysAndValues []interface{}) {
+	if level < s.min {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 < len(keysAndValues) {
+			fmt.Fprintf(&b, " %v=%v", keysAndValues[i], keysAndValues[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", keysAndValues[i])
+		}
+	}
+	s.l.Output(3, b.String())
+}
+
+// logger returns the Logger of the Requester, or one discarding everything.
+func (r *Requester) logger() Logger {
+	if r == nil || r.Logger == nil {
+		return nopLogger{}
+	}
+	return r.Logger
+}
@@ -0,0 +1,97 @@
+package gojenkins
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Handler sends an API request to Jenkins. Error statuses are returned as a
+// response, Requester.Do turns them into an *APIError after the middleware ran.
+type Handler func(ctx context.Context, ar *APIRequest) (*http.Response, error)
+
+// Middleware wraps a Handler, e.g. to add headers, record metrics or reject requests.
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the chain around every request. It must not be
+// called while requests are in flight.
+func (r *Requester) Use(middleware ...Middleware) *Requester {
+	r.Middleware = append(r.Middleware, middleware...)
+	return r
+}
+
+// RequestIDHeader carries the request ID set by the RequestID middleware.
+const RequestIDHeader = "X-Request-Id"
+
+type requestIDContextKey struct{}
+
+// ContextWithRequestID makes the RequestID middleware send id instead of a generated one.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestID sets the X-Request-Id header, to correlate calls with the Jenkins access log.
+// The ID comes from ContextWithRequestID, or is generated for every request.
+func RequestID() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, ar *APIRequest) (*http.Response, error) {
+			if ar.Headers.Get(RequestIDHeader) == "" {
+				id, ok := ctx.Value(requestIDContextKey{}).(string)
+				if !ok || id == "" {
+					id = newRequestID()
+				}
+				ar.SetHeader(RequestIDHeader, id)
+			}
+			return next(ctx, ar)
+		}
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// MetricsCollector records the outcome of every request.
+// status is zero when the request failed without a response.
+type MetricsCollector interface {
+	ObserveRequest(method string, endpoint string, status int, latency time.Duration, err error)
+}
+
+// Metrics reports the latency and status of every request to collector.
+// Retries and crumb refreshes are part of the measured latency.
+func Metrics(collector MetricsCollector) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, ar *APIRequest) (*http.Response, error) {
+			start := time.Now()
+			response, err := next(ctx, ar)
+			status := 0
+			if response != nil {
+				status = response.StatusCode
+			}
+			collector.ObserveRequest(ar.Method, ar.Endpoint, status, time.Since(start), err)
+			return response, err
+		}
+	}
+}
+
+// ErrReadOnly is returned for requests rejected by the ReadOnly middleware.
+var ErrReadOnly = errors.New("gojenkins: client is read-only")
+
+// ReadOnly rejects every request but GET before it is sent, e.g. for dashboards
+// which must never change Jenkins.
+func ReadOnly() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, ar *APIRequest) (*http.Response, error) {
+			if ar.Method != http.MethodGet {
+				return nil, fmt.Errorf("%w: %s %s", ErrReadOnly, ar.Method, ar.Endpoint)
+			}
+			return next(ctx, ar)
+		}
+	}
+}
@@ -154,7 +154,9 @@ func (n *Node) ToggleTemporarilyOffline(ctx context.Context, options ...interfac
 	}
 	qr := map[string]string{"offlineMessage": "requested from gojenkins"}
 	if len(options) > 0 {
-		qr["offlineMessage"] = options[0].(string)
+		if message, ok := options[0].(string); ok {
+			qr["offlineMessage"] = message
+		}
 	}
 	_, err = n.Jenkins.Requester.Post(ctx, n.Base+"/toggleOffline", nil, nil, qr)
 	if err != nil {
@@ -178,6 +180,17 @@ func (n *Node) Poll(ctx context.Context) (int, error) {
 	return response.StatusCode, nil
 }
 
+// PollFields fetches only the fields selected by tree. Fields left out are zero in Raw.
+func (n *Node) PollFields(ctx context.Context, tree Tree) (int, error) {
+	raw := new(NodeResponse)
+	response, err := n.Jenkins.Requester.GetJSON(ctx, n.Base, raw, tree.query())
+	if err != nil {
+		return 0, err
+	}
+	n.Raw = raw
+	return response.StatusCode, nil
+}
+
 func (n *Node) LaunchNodeBySSH(ctx context.Context) (int, error) {
 	qr := map[string]string{
 		"json":   "",
@@ -0,0 +1,276 @@
+package gojenkins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// clientOptions collects the settings of NewClient before the client is built.
+type clientOptions struct {
+	client        *http.Client
+	auth          Authenticator
+	basicAuth     *BasicAuth
+	tls           *TLSOptions
+	retry         *RetryPolicy
+	logger        Logger
+	trace         *TraceConfig
+	middleware    []Middleware
+	strictJSON    bool
+	cache         *CacheConfig
+	readLimit     Limit
+	writeLimit    Limit
+	disableCrumbs bool
+}
+
+// Option configures a client created by NewClient.
+type Option func(*clientOptions)
+
+// WithHTTPClient sends requests through client instead of a client owned by gojenkins.
+// A copy of client is used, whose redirect policy only sends the credentials again to
+// the same host. The CheckRedirect of client, if any, is still applied first.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *clientOptions) {
+		o.client = client
+	}
+}
+
+// WithBasicAuth authenticates with a username and password.
+func WithBasicAuth(username string, password string) Option {
+	return func(o *clientOptions) {
+		o.auth = &BasicAuth{Username: username, Password: password}
+	}
+}
+
+// WithAPIToken authenticates with a username and API token. No crumbs are fetched.
+func WithAPIToken(username string, token string) Option {
+	return func(o *clientOptions) {
+		o.auth = &APITokenAuth{Username: username, Token: token}
+	}
+}
+
+// WithBearerToken sends token as a bearer token, for reverse proxies in front of Jenkins.
+func WithBearerToken(token string) Option {
+	return func(o *clientOptions) {
+		o.auth = &BearerTokenAuth{Token: token}
+	}
+}
+
+// WithAuthenticator authenticates requests with auth.
+func WithAuthenticator(auth Authenticator) Option {
+	return func(o *clientOptions) {
+		o.auth = auth
+	}
+}
+
+// WithTLS configures certificate verification and client certificates, see TLSOptions.
+func WithTLS(options TLSOptions) Option {
+	return func(o *clientOptions) {
+		o.tls = &options
+	}
+}
+
+// WithRetry retries failed requests according to policy.
+func WithRetry(policy *RetryPolicy) Option {
+	return func(o *clientOptions) {
+		o.retry = policy
+	}
+}
+
+// WithLogger sends the log messages of the client to logger, see NewStdLogger and NewSlogLogger.
+func WithLogger(logger Logger) Option {
+	return func(o *clientOptions) {
+		o.logger = logger
+	}
+}
+
+// WithTrace records every HTTP round trip to trace.Writer or trace.Func, with secrets redacted.
+func WithTrace(trace *TraceConfig) Option {
+	return func(o *clientOptions) {
+		o.trace = trace
+	}
+}
+
+// WithMiddleware wraps every request with middleware, the first one is the outermost.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(o *clientOptions) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// WithStrictJSON fails requests whose JSON response has fields unknown to the response structs.
+func WithStrictJSON() Option {
+	return func(o *clientOptions) {
+		o.strictJSON = true
+	}
+}
+
+// WithLimits limits the rate and concurrency of reads and writes, see Requester.SetLimits.
+func WithLimits(read Limit, write Limit) Option {
+	return func(o *clientOptions) {
+		o.readLimit = read
+		o.writeLimit = write
+	}
+}
+
+// WithCache caches GET responses, see CacheConfig.
+func WithCache(config CacheConfig) Option {
+	return func(o *clientOptions) {
+		o.cache = &config
+	}
+}
+
+// WithoutCrumbs stops the client from fetching CSRF crumbs before POST requests.
+func WithoutCrumbs() Option {
+	return func(o *clientOptions) {
+		o.disableCrumbs = true
+	}
+}
+
+// NewClient creates a client for the Jenkins instance at base, e.g.
+//
+//	jenkins, err := gojenkins.NewClient("https://ci.example.com",
+//		gojenkins.WithAPIToken("admin", token),
+//		gojenkins.WithRetry(gojenkins.DefaultRetryPolicy()))
+//
+// Call Init on the result to check the connection.
+func NewClient(base string, opts ...Option) (*Jenkins, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("gojenkins: %q is not an absolute URL", base)
+	}
+
+	options := &clientOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	j, err := newJenkins(base, options)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// newJenkins builds the client described by options. The client is returned
+// even when the TLS configuration fails, for CreateJenkins to defer the error.
+func newJenkins(base string, options *clientOptions) (*Jenkins, error) {
+	base = strings.TrimSuffix(base, "/")
+	client := &http.Client{}
+	if options.client != nil {
+		// A copy, the redirect policy is not installed on the caller's client.
+		copied := *options.client
+		client = &copied
+	}
+	j := &Jenkins{
+		Server: base,
+		Requester: &Requester{
+			Base:          base,
+			Auth:          options.auth,
+			BasicAuth:     options.basicAuth,
+			Client:        client,
+			SslVerify:     true,
+			Retry:         options.retry,
+			Logger:        options.logger,
+			Trace:         options.trace,
+			Middleware:    options.middleware,
+			StrictJSON:    options.strictJSON,
+			DisableCrumbs: options.disableCrumbs,
+		},
+		Context: context.Background(),
+	}
+	client.CheckRedirect = j.Requester.chainRedirectPolicy(client.CheckRedirect)
+	j.Requester.SetLimits(options.readLimit, options.writeLimit)
+	j.Requester.SetCache(options.cache)
+
+	if options.tls != nil {
+		if err := j.Requester.ConfigureTLS(*options.tls); err != nil {
+			return j, err
+		}
+	}
+	return j, nil
+}
+
+// CreateJobOptions describes a job created by CreateJobWithOptions.
+type CreateJobOptions struct {
+	Name string
+	// Folders are the names of the parent folders, outermost first.
+	Folders []string
+}
+
+// CreateNodeOptions describes an agent created by CreateNodeWithOptions.
+type CreateNodeOptions struct {
+	Name         string
+	NumExecutors int
+	Description  string
+	RemoteFS     string
+	// Label holds the node labels, separated by blanks.
+	Label string
+	// Launcher starts the agent, nil means JNLPLauncher.
+	Launcher NodeLauncher
+}
+
+// NodeLauncher is the way Jenkins starts an agent, either JNLPLauncher or SSHLauncher.
+type NodeLauncher interface {
+	launcherConfig() map[string]string
+}
+
+// JNLPLauncher lets the agent connect to Jenkins by itself.
+type JNLPLauncher struct{}
+
+func (JNLPLauncher) launcherConfig() map[string]string {
+	return map[string]string{"stapler-class": "hudson.slaves.JNLPLauncher"}
+}
+
+// SSHLauncher starts the agent over SSH, it needs the SSH Build Agents plugin.
+// Zero values leave the plugin defaults.
+type SSHLauncher struct {
+	Host                 string
+	Port                 int
+	CredentialsID        string
+	JVMOptions           string
+	JavaPath             string
+	PrefixStartSlaveCmd  string
+	SuffixStartSlaveCmd  string
+	MaxNumRetries        int
+	RetryWaitTime        int
+	LaunchTimeoutSeconds int
+}
+
+func (l SSHLauncher) launcherConfig() map[string]string {
+	return map[string]string{
+		"stapler-class":        "hudson.plugins.sshslaves.SSHLauncher",
+		"$class":               "hudson.plugins.sshslaves.SSHLauncher",
+		"host":                 l.Host,
+		"port":                 optionalInt(l.Port),
+		"credentialsId":        l.CredentialsID,
+		"jvmOptions":           l.JVMOptions,
+		"javaPath":             l.JavaPath,
+		"prefixStartSlaveCmd":  l.PrefixStartSlaveCmd,
+		"suffixStartSlaveCmd":  l.SuffixStartSlaveCmd,
+		"maxNumRetries":        optionalInt(l.MaxNumRetries),
+		"retryWaitTime":        optionalInt(l.RetryWaitTime),
+		"launchTimeoutSeconds": optionalInt(l.LaunchTimeoutSeconds),
+		"type":                 "hudson.slaves.DumbSlave",
+		"stapler-class-bag":    "true",
+	}
+}
+
+// rawLauncher passes the launcher form fields of the deprecated CreateNode unchanged.
+type rawLauncher map[string]string
+
+func (l rawLauncher) launcherConfig() map[string]string {
+	return l
+}
+
+func optionalInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,186 @@
+package gojenkins
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// escapeSegment escapes name for use as a single path segment, so names with
+// blanks, #, %, ? or unicode characters reach the right resource.
+func escapeSegment(name string) string {
+	return url.PathEscape(name)
+}
+
+// JobPath returns the path of a job from its full name, e.g. team/service/deploy
+// becomes /job/team/job/service/job/deploy. Several names are joined as folders,
+// outermost first.
+func JobPath(fullName ...string) string {
+	var b strings.Builder
+	for _, name := range fullName {
+		for _, segment := range strings.Split(name, "/") {
+			if segment == "" {
+				continue
+			}
+			b.WriteString("/job/")
+			b.WriteString(escapeSegment(segment))
+		}
+	}
+	return b.String()
+}
+
+func nodePath(name string) string {
+	return "/computer/" + escapeSegment(name)
+}
+
+func viewPath(name string) string {
+	return "/view/" + escapeSegment(name)
+}
+
+func labelPath(name string) string {
+	return "/label/" + escapeSegment(name)
+}
+
+// Ref identifies a job, build, node, view, label or queue item, as parsed from its URL by ParseURL.
+type Ref struct {
+	// Kind is KindJob for jobs and folders alike, KindBuild, KindNode, KindView, KindLabel or KindQueueItem.
+	Kind ResourceKind
+	// FullName is the full name of a job, or of the job of a build or the folder holding a view.
+	FullName string
+	// Configuration is the configuration of a matrix job, e.g. jdk=11,os=linux.
+	Configuration string
+	// Number is the number of a build or queue item.
+	Number int64
+	// Permalink names a build by a permalink like lastSuccessfulBuild instead of its Number.
+	Permalink string
+	// Name is the name of a node, view or label.
+	Name string
+	// ParentViews are the views holding a nested view, outermost first and
+	// separated by slashes like the folders in FullName, e.g. all/team.
+	ParentViews string
+}
+
+// permalinks are the build permalinks of every job.
+var permalinks = map[string]bool{
+	"lastBuild":             true,
+	"lastStableBuild":       true,
+	"lastSuccessfulBuild":   true,
+	"lastFailedBuild":       true,
+	"lastUnstableBuild":     true,
+	"lastUnsuccessfulBuild": true,
+	"lastCompletedBuild":    true,
+	"firstBuild":            true,
+}
+
+// Path returns the escaped path of the resource below the Jenkins URL.
+func (r Ref) Path() string {
+	job := JobPath(r.FullName)
+	if r.Configuration != "" {
+		job += "/" + escapeSegment(r.Configuration)
+	}
+	switch r.Kind {
+	case KindBuild:
+		if r.Permalink != "" {
+			return job + "/" + r.Permalink
+		}
+		return job + "/" + strconv.FormatInt(r.Number, 10)
+	case KindNode:
+		return nodePath(r.Name)
+	case KindView:
+		for _, parent := range strings.Split(r.ParentViews, "/") {
+			if parent != "" {
+				job += viewPath(parent)
+			}
+		}
+		return job + viewPath(r.Name)
+	case KindLabel:
+		return labelPath(r.Name)
+	case KindQueueItem:
+		return "/queue/item/" + strconv.FormatInt(r.Number, 10)
+	}
+	return job
+}
+
+// ParseURL parses the URL of a job, build, node, view, label or queue item of this Jenkins.
+// rawURL may be absolute, with any host, or a path. It must start with the context
+// path of the Jenkins URL, e.g. /jenkins for https://example.com/jenkins.
+// Trailing segments like console or api/json are ignored.
+func (j *Jenkins) ParseURL(rawURL string) (Ref, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Ref{}, err
+	}
+	contextPath := ""
+	if server, err := url.Parse(j.Server); err == nil {
+		contextPath = strings.TrimSuffix(server.EscapedPath(), "/")
+	}
+	path := u.EscapedPath()
+	if contextPath != "" {
+		if path != contextPath && !strings.HasPrefix(path, contextPath+"/") {
+			return Ref{}, fmt.Errorf("gojenkins: %s is not below the Jenkins context path %s", rawURL, contextPath)
+		}
+		path = path[len(contextPath):]
+	}
+	return parsePath(rawURL, path)
+}
+
+func parsePath(rawURL string, path string) (Ref, error) {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return Ref{}, fmt.Errorf("gojenkins: invalid Jenkins URL %s: %w", rawURL, err)
+		}
+		segments = append(segments, unescaped)
+	}
+
+	var ref Ref
+	var jobs []string
+	for len(segments) >= 2 && segments[0] == "job" {
+		jobs = append(jobs, segments[1])
+		segments = segments[2:]
+	}
+	ref.FullName = strings.Join(jobs, "/")
+	if len(jobs) > 0 && len(segments) > 0 && strings.Contains(segments[0], "=") {
+		ref.Configuration = segments[0]
+		segments = segments[1:]
+	}
+
+	switch {
+	case len(segments) >= 2 && segments[0] == "view":
+		var views []string
+		for len(segments) >= 2 && segments[0] == "view" {
+			views = append(views, segments[1])
+			segments = segments[2:]
+		}
+		ref.Kind, ref.Name = KindView, views[len(views)-1]
+		ref.ParentViews = strings.Join(views[:len(views)-1], "/")
+	case len(jobs) > 0:
+		ref.Kind = KindJob
+		if len(segments) == 0 {
+			break
+		}
+		if number, err := strconv.ParseInt(segments[0], 10, 64); err == nil {
+			ref.Kind, ref.Number = KindBuild, number
+		} else if permalinks[segments[0]] {
+			ref.Kind, ref.Permalink = KindBuild, segments[0]
+		}
+	case len(segments) >= 2 && segments[0] == "computer":
+		ref.Kind, ref.Name = KindNode, segments[1]
+	case len(segments) >= 2 && segments[0] == "label":
+		ref.Kind, ref.Name = KindLabel, segments[1]
+	case len(segments) >= 3 && segments[0] == "queue" && segments[1] == "item":
+		number, err := strconv.ParseInt(segments[2], 10, 64)
+		if err != nil {
+			return Ref{}, fmt.Errorf("gojenkins: invalid queue item in %s", rawURL)
+		}
+		ref.Kind, ref.Number = KindQueueItem, number
+	default:
+		return Ref{}, fmt.Errorf("gojenkins: %s is not the URL of a job, build, node, view, label or queue item", rawURL)
+	}
+	return ref, nil
+}
@@ -2,7 +2,6 @@ package gojenkins
 
 import (
 	"context"
-	"fmt"
 	"regexp"
 )
 
@@ -82,6 +81,9 @@ func (run *PipelineRun) update() {
 }
 
 func (job *Job) GetPipelineRuns(ctx context.Context) (pr []PipelineRun, err error) {
+	if err := job.Jenkins.requirePlugin("GetPipelineRuns", PluginPipelineRESTAPI); err != nil {
+		return nil, err
+	}
 	_, err = job.Jenkins.Requester.GetJSON(ctx, job.Base+"/wfapi/runs", &pr, nil)
 	if err != nil {
 		return nil, err
@@ -95,6 +97,9 @@ func (job *Job) GetPipelineRuns(ctx context.Context) (pr []PipelineRun, err erro
 }
 
 func (job *Job) GetPipelineRun(ctx context.Context, id string) (pr *PipelineRun, err error) {
+	if err := job.Jenkins.requirePlugin("GetPipelineRun", PluginPipelineRESTAPI); err != nil {
+		return nil, err
+	}
 	pr = new(PipelineRun)
 	href := job.Base + "/" + id + "/wfapi/describe"
 	_, err = job.Jenkins.Requester.GetJSON(ctx, href, pr, nil)
@@ -108,6 +113,9 @@ func (job *Job) GetPipelineRun(ctx context.Context, id string) (pr *PipelineRun,
 }
 
 func (pr *PipelineRun) GetPendingInputActions(ctx context.Context) (PIAs []PipelineInputAction, err error) {
+	if err := pr.Job.Jenkins.requirePlugin("GetPendingInputActions", PluginPipelineRESTAPI); err != nil {
+		return nil, err
+	}
 	PIAs = make([]PipelineInputAction, 0, 1)
 	href := pr.Base + "/wfapi/pendingInputActions"
 	_, err = pr.Job.Jenkins.Requester.GetJSON(ctx, href, &PIAs, nil)
@@ -119,6 +127,9 @@ func (pr *PipelineRun) GetPendingInputActions(ctx context.Context) (PIAs []Pipel
 }
 
 func (pr *PipelineRun) GetArtifacts(ctx context.Context) (artifacts []PipelineArtifact, err error) {
+	if err := pr.Job.Jenkins.requirePlugin("GetArtifacts", PluginPipelineRESTAPI); err != nil {
+		return nil, err
+	}
 	artifacts = make([]PipelineArtifact, 0, 0)
 	href := pr.Base + "/wfapi/artifacts"
 	_, err = pr.Job.Jenkins.Requester.GetJSON(ctx, href, artifacts, nil)
@@ -130,6 +141,9 @@ func (pr *PipelineRun) GetArtifacts(ctx context.Context) (artifacts []PipelineAr
 }
 
 func (pr *PipelineRun) GetNode(ctx context.Context, id string) (node *PipelineNode, err error) {
+	if err := pr.Job.Jenkins.requirePlugin("GetNode", PluginPipelineRESTAPI); err != nil {
+		return nil, err
+	}
 	node = new(PipelineNode)
 	href := pr.Base + "/execution/node/" + id + "/wfapi/describe"
 	_, err = pr.Job.Jenkins.Requester.GetJSON(ctx, href, node, nil)
@@ -141,9 +155,11 @@ func (pr *PipelineRun) GetNode(ctx context.Context, id string) (node *PipelineNo
 }
 
 func (node *PipelineNode) GetLog(ctx context.Context) (log *PipelineNodeLog, err error) {
+	if err := node.Run.Job.Jenkins.requirePlugin("GetLog", PluginPipelineRESTAPI); err != nil {
+		return nil, err
+	}
 	log = new(PipelineNodeLog)
 	href := node.Base + "/wfapi/log"
-	fmt.Println(href)
 	_, err = node.Run.Job.Jenkins.Requester.GetJSON(ctx, href, log, nil)
 	if err != nil {
 		return nil, err
@@ -60,3 +60,14 @@ func (p *Plugins) Poll(ctx context.Context) (int, error) {
 	}
 	return response.StatusCode, nil
 }
+
+// PollFields fetches only the fields selected by tree. Fields left out are zero in Raw.
+func (p *Plugins) PollFields(ctx context.Context, tree Tree) (int, error) {
+	raw := new(PluginResponse)
+	response, err := p.Jenkins.Requester.GetJSON(ctx, p.Base, raw, tree.query())
+	if err != nil {
+		return 0, err
+	}
+	p.Raw = raw
+	return response.StatusCode, nil
+}
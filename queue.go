@@ -127,10 +127,34 @@ func (q *Queue) Poll(ctx context.Context) (int, error) {
 	return response.StatusCode, nil
 }
 
+// PollFields fetches only the fields selected by tree. Fields left out are zero in Raw.
+func (q *Queue) PollFields(ctx context.Context, tree Tree) (int, error) {
+	raw := new(queueResponse)
+	response, err := q.Jenkins.Requester.GetJSON(ctx, q.Base, raw, tree.query())
+	if err != nil {
+		return 0, err
+	}
+	q.Raw = raw
+	return response.StatusCode, nil
+}
+
+// Poll fetches the current state of the queue item. It is never read from the
+// response cache, queue items are polled until they leave the queue.
 func (t *Task) Poll(ctx context.Context) (int, error) {
-	response, err := t.Jenkins.Requester.GetJSON(ctx, t.Base, t.Raw, nil)
+	response, err := t.Jenkins.Requester.GetJSON(ContextWithoutCache(ctx), t.Base, t.Raw, nil)
+	if err != nil {
+		return 0, err
+	}
+	return response.StatusCode, nil
+}
+
+// PollFields fetches only the fields selected by tree. Fields left out are zero in Raw.
+func (t *Task) PollFields(ctx context.Context, tree Tree) (int, error) {
+	raw := new(taskResponse)
+	response, err := t.Jenkins.Requester.GetJSON(ctx, t.Base, raw, tree.query())
 	if err != nil {
 		return 0, err
 	}
+	t.Raw = raw
 	return response.StatusCode, nil
 }
@@ -0,0 +1,427 @@
+package gojenkins
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultReconcileConcurrency is the number of requests in flight when ReconcileOptions.Concurrency is not set.
+const defaultReconcileConcurrency = 4
+
+// JobAction is what a JobChange does to a job or folder.
+type JobAction string
+
+const (
+	ActionCreate JobAction = "create"
+	ActionUpdate JobAction = "update"
+	ActionDelete JobAction = "delete"
+)
+
+// ReconcileOptions configure PlanJobs, ApplyJobPlan and ReconcileJobs.
+type ReconcileOptions struct {
+	// Folder is the full name of the folder the directory is reconciled with, the root if empty.
+	Folder string
+	// Prune deletes the jobs and folders missing from the directory.
+	Prune bool
+	// Concurrency limits the requests in flight, 4 if not set.
+	Concurrency int
+	// DryRun makes ReconcileJobs stop after the plan.
+	DryRun bool
+}
+
+// JobChange is a change of the plan made by PlanJobs.
+type JobChange struct {
+	Action JobAction
+	// FullName is the full name of the job or folder on Jenkins.
+	FullName string
+	// Config is the local config.xml, empty for deletes and folders without one.
+	Config string
+	// Diff is the unified diff between the normalized configs on Jenkins and on disk.
+	Diff string
+}
+
+// JobPlan lists the changes that make Jenkins match a directory of jobs, parents before children.
+type JobPlan struct {
+	Folder  string
+	Changes []JobChange
+}
+
+// String lists the changes like "+ team/deploy", "~ team/build" followed by its diff, or "- old".
+func (p *JobPlan) String() string {
+	var b strings.Builder
+	for _, change := range p.Changes {
+		switch change.Action {
+		case ActionCreate:
+			b.WriteString("+ " + change.FullName + "\n")
+		case ActionUpdate:
+			b.WriteString("~ " + change.FullName + "\n" + change.Diff)
+		case ActionDelete:
+			b.WriteString("- " + change.FullName + "\n")
+		}
+	}
+	return b.String()
+}
+
+// JobChangeResult is the outcome of a change applied by ApplyJobPlan.
+type JobChangeResult struct {
+	JobChange
+	Err error
+}
+
+// ApplyReport is the outcome of every change of a plan, in the order of the plan.
+type ApplyReport struct {
+	DryRun  bool
+	Results []JobChangeResult
+}
+
+// Failed returns the changes that failed.
+func (r *ApplyReport) Failed() []JobChangeResult {
+	var failed []JobChangeResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err summarizes the failed changes, nil if none failed.
+func (r *ApplyReport) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	messages := make([]string, len(failed))
+	for i, result := range failed {
+		messages[i] = fmt.Sprintf("%s %s: %v", result.Action, result.FullName, result.Err)
+	}
+	return fmt.Errorf("gojenkins: %d of %d changes failed: %s", len(failed), len(r.Results), strings.Join(messages, "; "))
+}
+
+// localItem is a job or folder read from the directory.
+type localItem struct {
+	name   string
+	config string
+	folder bool
+}
+
+// readJobDir reads the jobs kept in dir as <name>/config.xml, nested in folders.
+// A directory is a folder if it has no config.xml or a folder's one, and hidden
+// directories are ignored.
+func readJobDir(dir string, prefix string, items *[]localItem) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		item := localItem{name: path.Join(prefix, entry.Name()), folder: true}
+		data, err := ioutil.ReadFile(filepath.Join(dir, entry.Name(), "config.xml"))
+		switch {
+		case err == nil:
+			item.config = string(data)
+			root, err := parseConfigTree(item.config)
+			if err != nil {
+				return fmt.Errorf("gojenkins: %s: %w", filepath.Join(dir, entry.Name(), "config.xml"), err)
+			}
+			item.folder = root.name == ClassFolder
+		case !os.IsNotExist(err):
+			return err
+		}
+		*items = append(*items, item)
+		if item.folder {
+			if err := readJobDir(filepath.Join(dir, entry.Name()), item.name, items); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// listItems returns the items below the folder fullName by full name, with their class.
+// Only plain folders are walked, the items of multibranch projects and organization
+// folders are managed by Jenkins.
+func (j *Jenkins) listItems(ctx context.Context, fullName string, concurrency int) (map[string]string, error) {
+	items := map[string]string{}
+	err := j.WalkJobs(ctx, WalkOptions{Folder: fullName, Concurrency: concurrency}, func(item Item) error {
+		items[item.FullName] = item.Class
+		if item.Folder && item.Class != ClassFolder {
+			return SkipFolder
+		}
+		return nil
+	})
+	return items, err
+}
+
+// PlanJobs compares the jobs kept in dir with those in opts.Folder. Every job is a
+// directory holding its config.xml, named after the job. Directories of folders hold
+// the jobs of the folder, and a config.xml if the folder has settings. Configs are
+// compared once normalized with NormalizeConfig, so formatting and comments do not
+// show up as changes. Jobs and configs are always read from Jenkins, never from the
+// response cache.
+func (j *Jenkins) PlanJobs(ctx context.Context, dir string, opts ReconcileOptions) (*JobPlan, error) {
+	ctx = ContextWithoutCache(ctx)
+	opts.Folder = strings.Trim(opts.Folder, "/")
+	var local []localItem
+	if err := readJobDir(dir, "", &local); err != nil {
+		return nil, err
+	}
+	remote, err := j.listItems(ctx, opts.Folder, opts.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &JobPlan{Folder: opts.Folder}
+	var compare []JobChange
+	kept := map[string]bool{opts.Folder: true}
+	for _, item := range local {
+		fullName := path.Join(opts.Folder, item.name)
+		kept[fullName] = true
+		class, exists := remote[fullName]
+		switch {
+		case !exists:
+			change := JobChange{Action: ActionCreate, FullName: fullName, Config: item.config}
+			if item.config != "" {
+				config, err := NormalizeConfig(item.config)
+				if err != nil {
+					return nil, fmt.Errorf("gojenkins: config.xml of %s: %w", fullName, err)
+				}
+				change.Diff = ConfigDiff(fullName+"/config.xml", "", config)
+			}
+			plan.Changes = append(plan.Changes, change)
+		case item.config != "":
+			compare = append(compare, JobChange{Action: ActionUpdate, FullName: fullName, Config: item.config})
+		case class != ClassFolder:
+			return nil, fmt.Errorf("gojenkins: %s is a %s, not a folder", fullName, class)
+		}
+	}
+
+	diffs := make([]string, len(compare))
+	errs := make([]error, len(compare))
+	forEach(len(compare), opts.Concurrency, func(i int) {
+		diffs[i], errs[i] = j.diffConfig(ctx, compare[i].FullName, compare[i].Config)
+	})
+	for i, change := range compare {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if diffs[i] != "" {
+			change.Diff = diffs[i]
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+
+	if opts.Prune {
+		for fullName := range remote {
+			parent := path.Dir(fullName)
+			if parent == "." {
+				parent = ""
+			}
+			if !kept[fullName] && kept[parent] {
+				plan.Changes = append(plan.Changes, JobChange{Action: ActionDelete, FullName: fullName})
+			}
+		}
+	}
+	sort.SliceStable(plan.Changes, func(a, b int) bool {
+		return plan.Changes[a].FullName < plan.Changes[b].FullName
+	})
+	return plan, nil
+}
+
+// diffConfig returns the diff between the normalized config of the job fullName and config.
+func (j *Jenkins) diffConfig(ctx context.Context, fullName string, config string) (string, error) {
+	job := &Job{Jenkins: j, Raw: new(JobResponse), Base: JobPath(fullName)}
+	current, err := job.GetConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	if normalized, err := NormalizeConfig(current); err == nil {
+		current = normalized
+	}
+	config, err = NormalizeConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("gojenkins: config.xml of %s: %w", fullName, err)
+	}
+	return ConfigDiff(fullName+"/config.xml", current, config), nil
+}
+
+// ApplyJobPlan makes the changes of plan, opts.Concurrency at a time. Folders are
+// created before their jobs, whose changes fail without being tried if their
+// folder could not be created. Every change is reported, the error sums up the
+// failed ones.
+func (j *Jenkins) ApplyJobPlan(ctx context.Context, plan *JobPlan, opts ReconcileOptions) (*ApplyReport, error) {
+	report := &ApplyReport{Results: make([]JobChangeResult, len(plan.Changes))}
+	levels := map[int][]int{}
+	maxLevel := 0
+	for i, change := range plan.Changes {
+		report.Results[i].JobChange = change
+		level := strings.Count(change.FullName, "/")
+		levels[level] = append(levels[level], i)
+		if level > maxLevel {
+			maxLevel = level
+		}
+	}
+
+	failed := map[string]bool{}
+	for level := 0; level <= maxLevel; level++ {
+		changes := levels[level]
+		forEach(len(changes), opts.Concurrency, func(i int) {
+			result := &report.Results[changes[i]]
+			if parent := path.Dir(result.FullName); failed[parent] {
+				result.Err = fmt.Errorf("gojenkins: folder %s was not created", parent)
+				return
+			}
+			result.Err = j.applyJobChange(ctx, result.JobChange)
+		})
+		for _, i := range changes {
+			if result := report.Results[i]; result.Err != nil && result.Action == ActionCreate {
+				failed[result.FullName] = true
+			}
+		}
+	}
+	return report, report.Err()
+}
+
+func (j *Jenkins) applyJobChange(ctx context.Context, change JobChange) error {
+	var parents []string
+	name := change.FullName
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		parents, name = []string{name[:i]}, name[i+1:]
+	}
+	job := &Job{Jenkins: j, Raw: new(JobResponse), Base: JobPath(change.FullName)}
+	var err error
+	switch change.Action {
+	case ActionCreate:
+		if change.Config == "" {
+			_, err = j.CreateFolder(ctx, name, parents...)
+		} else {
+			_, err = j.CreateJobInFolder(ctx, change.Config, name, parents...)
+		}
+	case ActionUpdate:
+		err = job.UpdateConfig(ctx, change.Config)
+	case ActionDelete:
+		_, err = job.Delete(ctx)
+	default:
+		err = fmt.Errorf("gojenkins: unknown action %q", change.Action)
+	}
+	return err
+}
+
+// ReconcileJobs makes the jobs in opts.Folder match those kept in dir, see PlanJobs.
+// With opts.DryRun only the plan is made, and the report is empty.
+func (j *Jenkins) ReconcileJobs(ctx context.Context, dir string, opts ReconcileOptions) (*JobPlan, *ApplyReport, error) {
+	plan, err := j.PlanJobs(ctx, dir, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.DryRun {
+		return plan, &ApplyReport{DryRun: true}, nil
+	}
+	report, err := j.ApplyJobPlan(ctx, plan, opts)
+	return plan, report, err
+}
+
+// forEach calls f for 0 to n-1, at most concurrency calls at a time.
+func forEach(n int, concurrency int, f func(i int)) {
+	if concurrency <= 0 {
+		concurrency = defaultReconcileConcurrency
+	}
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// NormalizeConfig rewrites config.xml in a canonical form to compare configs: without
+// declaration and comments, with sorted attributes, empty elements closed as <a/> and
+// elements indented by two blanks. Text is kept as is unless it is only whitespace.
+func NormalizeConfig(config string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(xmlDeclaration.ReplaceAllString(config, "")))
+	var stack []*normalElement
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			return "", errors.New("invalid config.xml: no root element")
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid config.xml: %w", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			e := &normalElement{name: qualifiedName(token.Name)}
+			for _, attr := range token.Attr {
+				e.attrs = append(e.attrs, " "+qualifiedName(attr.Name)+`="`+escapeAttr(attr.Value)+`"`)
+			}
+			sort.Strings(e.attrs)
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			}
+			stack = append(stack, e)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(token)
+			}
+		case xml.EndElement:
+			if len(stack) == 0 || qualifiedName(token.Name) != stack[len(stack)-1].name {
+				return "", fmt.Errorf("invalid config.xml: unexpected </%s>", qualifiedName(token.Name))
+			}
+			e := stack[len(stack)-1]
+			if stack = stack[:len(stack)-1]; len(stack) == 0 {
+				var b strings.Builder
+				e.write(&b, "")
+				return b.String(), nil
+			}
+		}
+	}
+}
+
+type normalElement struct {
+	name     string
+	attrs    []string
+	text     bytes.Buffer
+	children []*normalElement
+}
+
+func (e *normalElement) write(b *strings.Builder, indent string) {
+	b.WriteString(indent + "<" + e.name + strings.Join(e.attrs, ""))
+	text := e.text.String()
+	switch {
+	case len(e.children) > 0:
+		b.WriteString(">\n")
+		if text := strings.TrimSpace(text); text != "" {
+			b.WriteString(indent + "  " + escapeText(text) + "\n")
+		}
+		for _, child := range e.children {
+			child.write(b, indent+"  ")
+		}
+		b.WriteString(indent + "</" + e.name + ">\n")
+	case strings.TrimSpace(text) == "":
+		b.WriteString("/>\n")
+	default:
+		b.WriteString(">" + escapeText(text) + "</" + e.name + ">\n")
+	}
+}
@@ -1,21 +1,23 @@
 package gojenkins
 
 import (
+	"bufio"
 	"bytes"
+	"container/list"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"mime/multipart"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Request Methods
@@ -26,6 +28,7 @@ type APIRequest struct {
 	Payload  io.Reader
 	Headers  http.Header
 	Suffix   string
+	Query    url.Values
 }
 
 func (ar *APIRequest) SetHeader(key string, value string) *APIRequest {
@@ -36,30 +39,81 @@ func (ar *APIRequest) SetHeader(key string, value string) *APIRequest {
 func NewAPIRequest(method string, endpoint string, payload io.Reader) *APIRequest {
 	var headers = http.Header{}
 	var suffix string
-	ar := &APIRequest{method, endpoint, payload, headers, suffix}
+	ar := &APIRequest{Method: method, Endpoint: endpoint, Payload: payload, Headers: headers, Suffix: suffix}
 	return ar
 }
 
 type Requester struct {
-	Base      string
+	Base string
+	// Auth authenticates every request, unless overridden through ContextWithAuthenticator.
+	Auth Authenticator
+	// Deprecated: use Auth instead.
 	BasicAuth *BasicAuth
 	Client    *http.Client
-	CACert    []byte
+	// CACert, ClientCert, ClientKey, MinTLSVersion and InsecureSkipVerify are applied by ConfigureTLS.
+	CACert        []byte
+	ClientCert    []byte
+	ClientKey     []byte
+	MinTLSVersion uint16
+	// InsecureSkipVerify disables verification of the server certificate. Only use it for tests.
+	InsecureSkipVerify bool
+	// Deprecated: SslVerify is ignored, certificates are verified unless InsecureSkipVerify is set.
 	SslVerify bool
+	// Retry enables retries of failed requests, nil disables them.
+	Retry *RetryPolicy
+	// Logger receives the log messages of the client, nil discards them.
+	Logger Logger
+	// Trace records every HTTP round trip, with secrets redacted. nil disables tracing.
+	Trace *TraceConfig
+	// Middleware wraps every request, the first one is the outermost. See Use.
+	Middleware []Middleware
+	// StrictJSON rejects JSON fields unknown to the response structs, to catch API drift in tests.
+	StrictJSON bool
+	// DisableCrumbs skips fetching a CSRF crumb before POST requests.
+	// Jenkins does not require crumbs from clients authenticated with an API token.
+	DisableCrumbs bool
+
+	// crumbsMu guards noServerCrumbs, set when ProbeCapabilities found CSRF protection disabled.
+	crumbsMu       sync.Mutex
+	noServerCrumbs bool
+	// sessions are the crumb and cookie sessions by user, see getSession.
+	sessionMu    sync.Mutex
+	sessions     map[interface{}]*list.Element
+	sessionOrder *list.List
+	// cache is configured by SetCache.
+	cache *responseCache
+	// readLimiter and writeLimiter are configured by SetLimits.
+	readLimiter  *limiter
+	writeLimiter *limiter
+	// configErr records a configuration error of CreateJenkins, returned by every request.
+	configErr error
 }
 
+// SetCrumb adds the CSRF crumb header to ar. The crumb is fetched once and cached
+// together with the session cookies it is bound to.
 func (r *Requester) SetCrumb(ctx context.Context, ar *APIRequest) error {
-	crumbData := map[string]string{}
-	response, _ := r.GetJSON(ctx, "/crumbIssuer/api/json", &crumbData, nil)
-
-	if response.StatusCode == 200 && crumbData["crumbRequestField"] != "" {
-		ar.SetHeader(crumbData["crumbRequestField"], crumbData["crumb"])
-		ar.SetHeader("Cookie", response.Header.Get("set-cookie"))
+	if !r.crumbsRequired(ctx) || !r.serverCrumbs() {
+		return nil
+	}
+	c, err := r.getSession(ctx).getCrumb(ctx, r)
+	if err != nil {
+		return err
+	}
+	if c != nil {
+		ar.SetHeader(c.field, c.value)
 	}
-
 	return nil
 }
 
+// refreshCrumb replaces the crumb header of ar after Jenkins rejected it,
+// e.g. because the session it was bound to expired.
+func (r *Requester) refreshCrumb(ctx context.Context, ar *APIRequest) error {
+	if old := r.getSession(ctx).invalidateCrumb(); old != nil {
+		ar.Headers.Del(old.field)
+	}
+	return r.SetCrumb(ctx, ar)
+}
+
 func (r *Requester) PostJSON(ctx context.Context, endpoint string, payload io.Reader, responseStruct interface{}, querystring map[string]string) (*http.Response, error) {
 	ar := NewAPIRequest("POST", endpoint, payload)
 	if err := r.SetCrumb(ctx, ar); err != nil {
@@ -125,25 +179,50 @@ func (r *Requester) SetClient(client *http.Client) *Requester {
 }
 
 //Add auth on redirect if required.
+//Credentials are only sent again when the redirect stays on the original host.
 func (r *Requester) redirectPolicyFunc(req *http.Request, via []*http.Request) error {
-	if r.BasicAuth != nil {
-		req.SetBasicAuth(r.BasicAuth.Username, r.BasicAuth.Password)
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if req.URL.Host != via[0].URL.Host {
+		req.Header.Del("Authorization")
+		return nil
+	}
+	if auth := r.authenticator(req.Context()); auth != nil {
+		return auth.Authenticate(req)
 	}
 	return nil
 }
 
+// chainRedirectPolicy applies policy, the redirect policy of a client given by the caller,
+// before redirectPolicyFunc.
+func (r *Requester) chainRedirectPolicy(policy func(req *http.Request, via []*http.Request) error) func(req *http.Request, via []*http.Request) error {
+	if policy == nil {
+		return r.redirectPolicyFunc
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		if err := policy(req, via); err != nil {
+			return err
+		}
+		return r.redirectPolicyFunc(req, via)
+	}
+}
+
 func (r *Requester) Do(ctx context.Context, ar *APIRequest, responseStruct interface{}, options ...interface{}) (*http.Response, error) {
+	if r.configErr != nil {
+		return nil, r.configErr
+	}
+	release, err := r.limiter(ar.Method).acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	if !strings.HasSuffix(ar.Endpoint, "/") && ar.Method != "POST" {
 		ar.Endpoint += "/"
 	}
 
 	fileUpload := false
 	var files []string
-	URL, err := url.Parse(r.Base + ar.Endpoint + ar.Suffix)
-
-	if err != nil {
-		return nil, err
-	}
 
 	for _, o := range options {
 		switch v := o.(type) {
@@ -154,27 +233,25 @@ func (r *Requester) Do(ctx context.Context, ar *APIRequest, responseStruct inter
 				querystring.Set(key, val)
 			}
 
-			URL.RawQuery = querystring.Encode()
+			ar.Query = querystring
 		case []string:
 			fileUpload = true
 			files = v
 		}
 	}
-	var req *http.Request
 
 	if fileUpload {
-		body := &bytes.Buffer{}
-		writer := multipart.NewWriter(body)
+		buffer := &bytes.Buffer{}
+		writer := multipart.NewWriter(buffer)
 		for _, file := range files {
 			fileData, err := os.Open(file)
 			if err != nil {
-				Error.Println(err.Error())
+				r.logger().Error("can't open file for upload", "endpoint", ar.Endpoint, "file", file, "error", err)
 				return nil, err
 			}
 
 			part, err := writer.CreateFormFile("file", filepath.Base(file))
 			if err != nil {
-				Error.Println(err.Error())
 				return nil, err
 			}
 			if _, err = io.Copy(part, fileData); err != nil {
@@ -185,52 +262,41 @@ func (r *Requester) Do(ctx context.Context, ar *APIRequest, responseStruct inter
 		var params map[string]string
 		json.NewDecoder(ar.Payload).Decode(&params)
 		for key, val := range params {
-			if err = writer.WriteField(key, val); err != nil {
+			if err := writer.WriteField(key, val); err != nil {
 				return nil, err
 			}
 		}
-		if err = writer.Close(); err != nil {
+		if err := writer.Close(); err != nil {
 			return nil, err
 		}
-		req, err = http.NewRequest(ar.Method, URL.String(), body)
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Set("Content-Type", writer.FormDataContentType())
-	} else {
-
-		req, err = http.NewRequest(ar.Method, URL.String(), ar.Payload)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	if r.BasicAuth != nil {
-		req.SetBasicAuth(r.BasicAuth.Username, r.BasicAuth.Password)
+		ar.Payload = buffer
+		ar.SetHeader("Content-Type", writer.FormDataContentType())
 	}
 
-	for k := range ar.Headers {
-		req.Header.Add(k, ar.Headers.Get(k))
+	handler := Handler(r.roundTrip)
+	for i := len(r.Middleware) - 1; i >= 0; i-- {
+		handler = r.Middleware[i](handler)
 	}
+	response, err := handler(ctx, ar)
 
-	if response, err := r.Client.Do(req); err != nil {
+	if err != nil {
 		return nil, err
 	} else {
-		if v := ctx.Value("debug"); v != nil {
-			dump, err := httputil.DumpResponse(response, true)
-			if err != nil {
-				log.Fatal(err)
-			}
-			log.Printf("DEBUG %q\n", dump)
-		}
-		errorText := response.Header.Get("X-Error")
-		if errorText != "" {
-			return nil, errors.New(errorText)
+		r.logger().Debug("jenkins request", "method", ar.Method, "endpoint", ar.Endpoint, "status", response.StatusCode)
+		if response.StatusCode >= 400 || response.Header.Get("X-Error") != "" {
+			defer response.Body.Close()
+			return nil, newAPIError(response)
 		}
 		switch responseStruct.(type) {
 		case *string:
 			return r.ReadRawResponse(response, responseStruct)
 		default:
+			// Form posts answer with a redirect to an HTML page, only API calls return JSON.
+			if ar.Method == "POST" && !strings.HasSuffix(ar.Suffix, "api/json") {
+				io.Copy(ioutil.Discard, response.Body)
+				response.Body.Close()
+				return response, nil
+			}
 			return r.ReadJSONResponse(response, responseStruct)
 		}
 
@@ -238,6 +304,127 @@ func (r *Requester) Do(ctx context.Context, ar *APIRequest, responseStruct inter
 
 }
 
+// roundTrip is the innermost Handler, it sends ar to Jenkins.
+// Error statuses are returned as a response, for middleware to inspect.
+func (r *Requester) roundTrip(ctx context.Context, ar *APIRequest) (*http.Response, error) {
+	URL, err := url.Parse(r.Base + ar.Endpoint + ar.Suffix)
+	if err != nil {
+		return nil, err
+	}
+	if len(ar.Query) > 0 {
+		URL.RawQuery = ar.Query.Encode()
+	}
+
+	var body []byte
+	if ar.Payload != nil {
+		// The payload is buffered so the request can be sent again on retry.
+		if body, err = ioutil.ReadAll(ar.Payload); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.cache != nil && ar.Method == "POST" {
+		defer r.cache.invalidate(r.Base, ar.Endpoint)
+	}
+	if cache := r.cacheFor(ctx); cache != nil && ar.Method == "GET" && body == nil {
+		return cache.sendCached(ctx, r, ar, URL.String())
+	}
+
+	response, err := r.send(ctx, ar, URL.String(), body)
+	if err == nil && ar.Method == "POST" && response.StatusCode == http.StatusForbidden && r.crumbsRequired(ctx) {
+		data, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+		response.Body.Close()
+		response.Body = ioutil.NopCloser(bytes.NewReader(data))
+		rejection := &APIError{StatusCode: response.StatusCode, Message: response.Header.Get("X-Error"), Body: string(data)}
+		if !errors.Is(rejection, ErrCrumbRejected) {
+			return response, nil
+		}
+		// The cached crumb went stale, or CSRF protection was turned on since
+		// ProbeCapabilities, fetch a new one and try once more.
+		r.logger().Info("crumb rejected, fetching a new one", "method", ar.Method, "endpoint", ar.Endpoint)
+		r.setServerCrumbs(true)
+		if err := r.refreshCrumb(ctx, ar); err != nil {
+			return nil, err
+		}
+		response, err = r.send(ctx, ar, URL.String(), body)
+	}
+	return response, err
+}
+
+// send performs the HTTP round trip for ar, retrying according to r.Retry.
+func (r *Requester) send(ctx context.Context, ar *APIRequest, URL string, body []byte) (*http.Response, error) {
+	attempts := 1
+	if r.Retry != nil && r.Retry.allowsMethod(ar.Method) {
+		attempts = r.Retry.maxAttempts()
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := r.limiter(ar.Method).wait(ctx); err != nil {
+			return nil, err
+		}
+		req, err := r.newHTTPRequest(ctx, ar, URL, body)
+		if err != nil {
+			return nil, err
+		}
+
+		session := r.getSession(ctx)
+		session.addCookies(r.Client, req)
+		start := time.Now()
+		response, err := r.Client.Do(req)
+		if trace := r.tracer(ctx); trace != nil {
+			trace.record(req, body, attempt, response, err, time.Since(start))
+		}
+		if err == nil {
+			session.saveCookies(r.Client, req, response)
+		}
+		if err != nil && ctx.Err() != nil {
+			// Surface cancellation as is, instead of wrapped in a *url.Error.
+			return nil, ctx.Err()
+		}
+		if attempt >= attempts || !r.Retry.shouldRetry(response, err) {
+			return response, err
+		}
+
+		wait := r.Retry.backoff(attempt, response)
+		fields := []interface{}{"method", ar.Method, "endpoint", ar.Endpoint, "attempt", attempt, "backoff", wait}
+		if err != nil {
+			fields = append(fields, "error", err)
+		} else {
+			fields = append(fields, "status", response.StatusCode)
+		}
+		r.logger().Warn("retrying request", fields...)
+		if response != nil {
+			io.Copy(ioutil.Discard, response.Body)
+			response.Body.Close()
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (r *Requester) newHTTPRequest(ctx context.Context, ar *APIRequest, URL string, body []byte) (*http.Request, error) {
+	var payload io.Reader
+	if body != nil {
+		payload = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, ar.Method, URL, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if auth := r.authenticator(ctx); auth != nil {
+		if err := auth.Authenticate(req); err != nil {
+			return nil, err
+		}
+	}
+
+	for k := range ar.Headers {
+		req.Header.Add(k, ar.Headers.Get(k))
+	}
+	return req, nil
+}
+
 func (r *Requester) ReadRawResponse(response *http.Response, responseStruct interface{}) (*http.Response, error) {
 	defer response.Body.Close()
 
@@ -254,9 +441,21 @@ func (r *Requester) ReadRawResponse(response *http.Response, responseStruct inte
 	return response, nil
 }
 
+// ReadJSONResponse decodes the response body into responseStruct. An empty body leaves
+// responseStruct untouched, HTML pages and invalid JSON are reported as a *DecodeError.
 func (r *Requester) ReadJSONResponse(response *http.Response, responseStruct interface{}) (*http.Response, error) {
 	defer response.Body.Close()
 
-	json.NewDecoder(response.Body).Decode(responseStruct)
+	body := bufio.NewReader(response.Body)
+	if isHTML(response.Header.Get("Content-Type"), body) {
+		return nil, newDecodeError(response, ErrHTMLResponse)
+	}
+	decoder := json.NewDecoder(body)
+	if r.StrictJSON {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(responseStruct); err != nil && err != io.EOF {
+		return nil, newDecodeError(response, err)
+	}
 	return response, nil
 }
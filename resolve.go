@@ -0,0 +1,131 @@
+package gojenkins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// folderClasses are the classes of items holding other jobs, resolved to a *Folder.
+var folderClasses = map[string]bool{
+	ClassFolder:                         true,
+	"jenkins.branch.OrganizationFolder": true,
+	"org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject": true,
+}
+
+// Resolve returns the object at a Jenkins URL, e.g. https://ci/job/a/job/b/123/console:
+// a *Job, *Folder, *Build, *Node, *View, *Label or *Task, see ParseURL for the URLs understood.
+// Folders, organization folders and multibranch projects are returned as a *Folder, their
+// branches and matrix configurations as a *Job. Permalinks like lastSuccessfulBuild are
+// resolved to the build they point to. Unlike ParseURL, Resolve refuses absolute URLs
+// of another scheme or host than the Jenkins URL.
+func (j *Jenkins) Resolve(ctx context.Context, rawURL string) (Resource, error) {
+	if err := j.checkOrigin(rawURL); err != nil {
+		return nil, err
+	}
+	ref, err := j.ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch ref.Kind {
+	case KindJob, KindBuild:
+		job := &Job{Jenkins: j, Raw: new(JobResponse), Base: Ref{FullName: ref.FullName, Configuration: ref.Configuration}.Path()}
+		if err := Refresh(ctx, job); err != nil {
+			return nil, err
+		}
+		if ref.Kind == KindBuild {
+			build, err := job.resolveBuild(ctx, ref)
+			if err != nil {
+				return nil, err
+			}
+			return build, nil
+		}
+		if folderClasses[job.Raw.Class] {
+			folder := &Folder{Jenkins: j, Raw: new(FolderResponse), Base: job.Base}
+			if err := Refresh(ctx, folder); err != nil {
+				return nil, err
+			}
+			return folder, nil
+		}
+		return job, nil
+	case KindNode:
+		node, err := j.GetNode(ctx, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		return node, nil
+	case KindView:
+		view := &View{Jenkins: j, Raw: new(ViewResponse), Base: ref.Path()}
+		if err := Refresh(ctx, view); err != nil {
+			return nil, err
+		}
+		return view, nil
+	case KindLabel:
+		label, err := j.GetLabel(ctx, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		return label, nil
+	case KindQueueItem:
+		task, err := j.GetQueueItem(ctx, ref.Number)
+		if err != nil {
+			return nil, err
+		}
+		return task, nil
+	}
+	return nil, fmt.Errorf("gojenkins: cannot resolve %s", rawURL)
+}
+
+// checkOrigin fails if rawURL is absolute and not on the Jenkins server.
+func (j *Jenkins) checkOrigin(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || !u.IsAbs() {
+		return nil
+	}
+	server, err := url.Parse(j.Server)
+	if err != nil {
+		return nil
+	}
+	if !strings.EqualFold(u.Scheme, server.Scheme) || !strings.EqualFold(u.Hostname(), server.Hostname()) ||
+		hostPort(u) != hostPort(server) {
+		return fmt.Errorf("gojenkins: %s is not on the Jenkins server %s://%s", rawURL, server.Scheme, server.Host)
+	}
+	return nil
+}
+
+// hostPort returns the port of u, the default one of its scheme if not set.
+func hostPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
+
+func (j *Job) resolveBuild(ctx context.Context, ref Ref) (*Build, error) {
+	number := ref.Number
+	if ref.Permalink != "" {
+		builds := map[string]JobBuild{
+			"lastBuild":             j.Raw.LastBuild,
+			"lastStableBuild":       j.Raw.LastStableBuild,
+			"lastSuccessfulBuild":   j.Raw.LastSuccessfulBuild,
+			"lastFailedBuild":       j.Raw.LastFailedBuild,
+			"lastUnstableBuild":     j.Raw.LastUnstableBuild,
+			"lastUnsuccessfulBuild": j.Raw.LastUnsuccessfulBuild,
+			"lastCompletedBuild":    j.Raw.LastCompletedBuild,
+			"firstBuild":            j.Raw.FirstBuild,
+		}
+		number = builds[ref.Permalink].Number
+		if number == 0 {
+			return nil, unexpectedStatus("GET", j.Base+"/"+ref.Permalink, http.StatusNotFound)
+		}
+	}
+	return j.GetBuild(ctx, number)
+}
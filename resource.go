@@ -0,0 +1,168 @@
+package gojenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ResourceKind names the type of a Resource.
+type ResourceKind string
+
+const (
+	KindJob       ResourceKind = "job"
+	KindBuild     ResourceKind = "build"
+	KindNode      ResourceKind = "node"
+	KindView      ResourceKind = "view"
+	KindFolder    ResourceKind = "folder"
+	KindLabel     ResourceKind = "label"
+	KindQueue     ResourceKind = "queue"
+	KindQueueItem ResourceKind = "queueItem"
+)
+
+// Resource is a Jenkins object with its own remote API endpoint,
+// implemented by Job, Build, Node, View, Folder, Label, Queue and Task.
+type Resource interface {
+	// BasePath is the path of the resource below the Jenkins URL, e.g. /job/app.
+	BasePath() string
+	// AbsoluteURL is the URL of the resource, the Jenkins URL followed by BasePath.
+	AbsoluteURL() string
+	Kind() ResourceKind
+	// Fetch polls the current data of the resource into its Raw field, like its Poll method.
+	Fetch(ctx context.Context) (int, error)
+	// RawJSON encodes the Raw field of the resource, as fetched by the last poll.
+	RawJSON() (json.RawMessage, error)
+}
+
+var (
+	_ Resource = (*Job)(nil)
+	_ Resource = (*Build)(nil)
+	_ Resource = (*Node)(nil)
+	_ Resource = (*View)(nil)
+	_ Resource = (*Folder)(nil)
+	_ Resource = (*Label)(nil)
+	_ Resource = (*Queue)(nil)
+	_ Resource = (*Task)(nil)
+)
+
+// Refresh polls r, turning a status other than 200 into an *APIError. r is always
+// fetched from Jenkins, never from the response cache.
+func Refresh(ctx context.Context, r Resource) error {
+	status, err := r.Fetch(ContextWithoutCache(ctx))
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return unexpectedStatus("GET", r.BasePath(), status)
+	}
+	return nil
+}
+
+// Exists reports whether r exists on Jenkins, refreshing it when it does.
+func Exists(ctx context.Context, r Resource) (bool, error) {
+	err := Refresh(ctx, r)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// WaitUntil refreshes r every interval until done returns true for it or ctx is done.
+// Like Refresh, it bypasses the response cache, e.g.
+//
+//	err := gojenkins.WaitUntil(ctx, build, time.Second, func(gojenkins.Resource) bool { return !build.Raw.Building })
+func WaitUntil(ctx context.Context, r Resource, interval time.Duration, done func(Resource) bool) error {
+	for {
+		if err := Refresh(ctx, r); err != nil {
+			return err
+		}
+		if done(r) {
+			return nil
+		}
+		if err := sleepContext(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
+
+// ResourceSnapshot is the state of a resource at a point in time.
+type ResourceSnapshot struct {
+	Kind ResourceKind    `json:"kind"`
+	Path string          `json:"path"`
+	URL  string          `json:"url"`
+	Time time.Time       `json:"time"`
+	Raw  json.RawMessage `json:"raw"`
+}
+
+// Snapshot captures the state of r as fetched by its last poll.
+func Snapshot(r Resource) (*ResourceSnapshot, error) {
+	raw, err := r.RawJSON()
+	if err != nil {
+		return nil, err
+	}
+	return &ResourceSnapshot{
+		Kind: r.Kind(),
+		Path: r.BasePath(),
+		URL:  r.AbsoluteURL(),
+		Time: time.Now(),
+		Raw:  raw,
+	}, nil
+}
+
+func (j *Jenkins) absoluteURL(path string) string {
+	return j.Server + path
+}
+
+func rawJSON(raw interface{}) (json.RawMessage, error) {
+	return json.Marshal(raw)
+}
+
+func (j *Job) BasePath() string                       { return j.Base }
+func (j *Job) AbsoluteURL() string                    { return j.Jenkins.absoluteURL(j.Base) }
+func (j *Job) Kind() ResourceKind                     { return KindJob }
+func (j *Job) Fetch(ctx context.Context) (int, error) { return j.Poll(ctx) }
+func (j *Job) RawJSON() (json.RawMessage, error)      { return rawJSON(j.Raw) }
+
+func (b *Build) BasePath() string                       { return b.Base }
+func (b *Build) AbsoluteURL() string                    { return b.Jenkins.absoluteURL(b.Base) }
+func (b *Build) Kind() ResourceKind                     { return KindBuild }
+func (b *Build) Fetch(ctx context.Context) (int, error) { return b.PollDepth(ctx, b.Depth) }
+func (b *Build) RawJSON() (json.RawMessage, error)      { return rawJSON(b.Raw) }
+
+func (n *Node) BasePath() string                       { return n.Base }
+func (n *Node) AbsoluteURL() string                    { return n.Jenkins.absoluteURL(n.Base) }
+func (n *Node) Kind() ResourceKind                     { return KindNode }
+func (n *Node) Fetch(ctx context.Context) (int, error) { return n.Poll(ctx) }
+func (n *Node) RawJSON() (json.RawMessage, error)      { return rawJSON(n.Raw) }
+
+func (v *View) BasePath() string                       { return v.Base }
+func (v *View) AbsoluteURL() string                    { return v.Jenkins.absoluteURL(v.Base) }
+func (v *View) Kind() ResourceKind                     { return KindView }
+func (v *View) Fetch(ctx context.Context) (int, error) { return v.Poll(ctx) }
+func (v *View) RawJSON() (json.RawMessage, error)      { return rawJSON(v.Raw) }
+
+func (f *Folder) BasePath() string                       { return f.Base }
+func (f *Folder) AbsoluteURL() string                    { return f.Jenkins.absoluteURL(f.Base) }
+func (f *Folder) Kind() ResourceKind                     { return KindFolder }
+func (f *Folder) Fetch(ctx context.Context) (int, error) { return f.Poll(ctx) }
+func (f *Folder) RawJSON() (json.RawMessage, error)      { return rawJSON(f.Raw) }
+
+func (l *Label) BasePath() string                       { return l.Base }
+func (l *Label) AbsoluteURL() string                    { return l.Jenkins.absoluteURL(l.Base) }
+func (l *Label) Kind() ResourceKind                     { return KindLabel }
+func (l *Label) Fetch(ctx context.Context) (int, error) { return l.Poll(ctx) }
+func (l *Label) RawJSON() (json.RawMessage, error)      { return rawJSON(l.Raw) }
+
+func (q *Queue) BasePath() string                       { return q.Base }
+func (q *Queue) AbsoluteURL() string                    { return q.Jenkins.absoluteURL(q.Base) }
+func (q *Queue) Kind() ResourceKind                     { return KindQueue }
+func (q *Queue) Fetch(ctx context.Context) (int, error) { return q.Poll(ctx) }
+func (q *Queue) RawJSON() (json.RawMessage, error)      { return rawJSON(q.Raw) }
+
+func (t *Task) BasePath() string                       { return t.Base }
+func (t *Task) AbsoluteURL() string                    { return t.Jenkins.absoluteURL(t.Base) }
+func (t *Task) Kind() ResourceKind                     { return KindQueueItem }
+func (t *Task) Fetch(ctx context.Context) (int, error) { return t.Poll(ctx) }
+func (t *Task) RawJSON() (json.RawMessage, error)      { return rawJSON(t.Raw) }
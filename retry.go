@@ -0,0 +1,132 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how the Requester retries requests that fail with a
+// transient error, e.g. while the Jenkins controller restarts.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, it doubles on every further attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts, including waits requested through Retry-After.
+	MaxBackoff time.Duration
+	// Jitter randomizes each wait by up to this fraction, between 0 and 1.
+	Jitter float64
+	// RetryStatusCodes lists the response codes which are retried.
+	RetryStatusCodes []int
+	// RetryNonIdempotent allows POST requests such as /build or /createItem to be retried.
+	// Enabling it may trigger the same build or create the same item twice.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy retries gateway errors and connection resets of idempotent requests up to 4 times.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:      4,
+		InitialBackoff:   500 * time.Millisecond,
+		MaxBackoff:       10 * time.Second,
+		Jitter:           0.2,
+		RetryStatusCodes: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) allowsMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return p.RetryNonIdempotent
+}
+
+func (p *RetryPolicy) shouldRetry(response *http.Response, err error) bool {
+	if err != nil {
+		return isConnectionError(err)
+	}
+	for _, code := range p.RetryStatusCodes {
+		if response.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the attempt following the given one.
+func (p *RetryPolicy) backoff(attempt int, response *http.Response) time.Duration {
+	if response != nil {
+		if wait, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+			return p.capBackoff(wait)
+		}
+	}
+
+	wait := float64(p.InitialBackoff) * math.Pow(2, float64(attempt-1))
+	if p.Jitter > 0 {
+		delta := p.Jitter * wait
+		wait = wait - delta + rand.Float64()*2*delta
+	}
+	return p.capBackoff(time.Duration(wait))
+}
+
+func (p *RetryPolicy) capBackoff(wait time.Duration) time.Duration {
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return wait
+}
+
+// parseRetryAfter understands both forms of the Retry-After header: delay in seconds and HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// isConnectionError reports whether err means the connection was dropped or refused,
+// which is what clients see while Jenkins or the proxy in front of it restarts.
+func isConnectionError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package gojenkins
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+)
+
+// TLSOptions configures how the client verifies the Jenkins certificate and presents its own.
+type TLSOptions struct {
+	// CACert is a PEM bundle appended to the system certificate pool, e.g. an internal CA.
+	CACert []byte
+	// ClientCert and ClientKey are a PEM encoded certificate and private key for mutual TLS.
+	ClientCert []byte
+	ClientKey  []byte
+	// MinVersion is the lowest accepted TLS version, e.g. tls.VersionTLS12. Zero keeps the Go default.
+	MinVersion uint16
+	// InsecureSkipVerify disables verification of the server certificate. Only use it for tests.
+	InsecureSkipVerify bool
+}
+
+// ConfigureTLS stores opts on the Requester and installs a transport built from them.
+// The http.Client is copied first, so a client shared with other code is left untouched.
+func (r *Requester) ConfigureTLS(opts TLSOptions) error {
+	r.CACert = opts.CACert
+	r.ClientCert = opts.ClientCert
+	r.ClientKey = opts.ClientKey
+	r.MinTLSVersion = opts.MinVersion
+	r.InsecureSkipVerify = opts.InsecureSkipVerify
+	r.SslVerify = !opts.InsecureSkipVerify
+
+	tlsConfig, err := r.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	var transport *http.Transport
+	switch t := r.Client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return errors.New("gojenkins: TLS options need the client to use an *http.Transport")
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	client := *r.Client
+	client.Transport = transport
+	r.Client = &client
+	return nil
+}
+
+// TLSConfig builds the TLS configuration described by CACert, ClientCert, ClientKey,
+// MinTLSVersion and InsecureSkipVerify.
+func (r *Requester) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         r.MinTLSVersion,
+		InsecureSkipVerify: r.InsecureSkipVerify,
+	}
+
+	if len(r.CACert) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(r.CACert) {
+			return nil, errors.New("gojenkins: no valid certificate found in CACert")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(r.ClientCert) > 0 || len(r.ClientKey) > 0 {
+		certificate, err := tls.X509KeyPair(r.ClientCert, r.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,263 @@
+package gojenkins
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTraceBodyLimit is the number of body bytes recorded when TraceConfig.MaxBodySize is zero.
+const defaultTraceBodyLimit = 4096
+
+// traceRedactMargin is how many response bytes past TraceConfig.MaxBodySize are read,
+// so that a secret spanning the cut is still recognized and redacted.
+const traceRedactMargin = 16 << 10
+
+// redacted replaces secrets in traces.
+const redacted = "REDACTED"
+
+// TraceEvent describes one HTTP round trip with Jenkins. Credentials, crumbs, cookies and
+// password or secret fields are already redacted.
+type TraceEvent struct {
+	Method  string
+	URL     string
+	Attempt int
+	// StatusCode is zero when no response was received, see Err.
+	StatusCode int
+	Latency    time.Duration
+	Err        error
+
+	RequestHeader  http.Header
+	RequestBody    []byte
+	ResponseHeader http.Header
+	ResponseBody   []byte
+	// RequestTruncated and ResponseTruncated report bodies cut at TraceConfig.MaxBodySize.
+	RequestTruncated  bool
+	ResponseTruncated bool
+}
+
+// TraceConfig enables wire tracing of the requests sent to Jenkins.
+// Putting a "debug" value in the request context traces to the standard logger,
+// this is deprecated.
+type TraceConfig struct {
+	// Writer receives a textual dump of every round trip.
+	Writer io.Writer
+	// Func is called with every round trip.
+	Func func(*TraceEvent)
+	// MaxBodySize limits the recorded bodies, zero means 4096 bytes and a negative value omits them.
+	// At most 16 KiB more of a response body is read, to redact secrets before the body is cut.
+	MaxBodySize int
+
+	mu sync.Mutex
+}
+
+// tracer returns the trace configuration in use for ctx, or nil when tracing is off.
+func (r *Requester) tracer(ctx context.Context) *TraceConfig {
+	if r.Trace != nil {
+		return r.Trace
+	}
+	if ctx.Value("debug") != nil {
+		return legacyTrace
+	}
+	return nil
+}
+
+var legacyTrace = &TraceConfig{Writer: legacyTraceWriter{}}
+
+// legacyTraceWriter writes to the output of the standard logger at the time of the call.
+type legacyTraceWriter struct{}
+
+func (legacyTraceWriter) Write(p []byte) (int, error) {
+	return log.Writer().Write(p)
+}
+
+func (t *TraceConfig) bodyLimit() int {
+	if t.MaxBodySize == 0 {
+		return defaultTraceBodyLimit
+	}
+	return t.MaxBodySize
+}
+
+// record emits the trace of a round trip. The beginning of the response body is
+// read and handed back unchanged, followed by the rest of the body or the error
+// reading it, for the caller to read as usual.
+func (t *TraceConfig) record(req *http.Request, body []byte, attempt int, response *http.Response, err error, latency time.Duration) {
+	limit := t.bodyLimit()
+	event := &TraceEvent{
+		Method:        req.Method,
+		URL:           redactURL(req.URL),
+		Attempt:       attempt,
+		Latency:       latency,
+		Err:           err,
+		RequestHeader: redactHeader(req.Header),
+	}
+	// Secrets are redacted in the whole body, a cut could hide the end of the field holding them.
+	event.RequestBody, event.RequestTruncated = truncate(redactBody(req.Header.Get("Content-Type"), body), limit)
+
+	if response != nil {
+		event.StatusCode = response.StatusCode
+		event.ResponseHeader = redactHeader(response.Header)
+		if limit > 0 && response.Body != nil {
+			peeked, readErr := ioutil.ReadAll(io.LimitReader(response.Body, int64(limit)+traceRedactMargin))
+			rest := response.Body.(io.Reader)
+			if readErr != nil {
+				rest = errorReader{readErr}
+			}
+			response.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(peeked), rest), response.Body}
+			event.ResponseBody, event.ResponseTruncated = truncate(redactBody(response.Header.Get("Content-Type"), peeked), limit)
+		}
+	}
+
+	if t.Func != nil {
+		t.Func(event)
+	}
+	if t.Writer != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		io.WriteString(t.Writer, event.String())
+	}
+}
+
+// errorReader fails every read with err.
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// String formats the event like an HTTP exchange, for TraceConfig.Writer.
+func (e *TraceEvent) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--> %s %s (attempt %d)\n", e.Method, e.URL, e.Attempt)
+	writeHeader(&b, e.RequestHeader)
+	writeBody(&b, e.RequestBody, e.RequestTruncated)
+	if e.Err != nil {
+		fmt.Fprintf(&b, "<-- %s %s error: %v (%s)\n", e.Method, e.URL, e.Err, e.Latency)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "<-- %d %s %s (%s)\n", e.StatusCode, e.Method, e.URL, e.Latency)
+	writeHeader(&b, e.ResponseHeader)
+	writeBody(&b, e.ResponseBody, e.ResponseTruncated)
+	return b.String()
+}
+
+func writeHeader(b *strings.Builder, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			fmt.Fprintf(b, "%s: %s\n", k, v)
+		}
+	}
+}
+
+func writeBody(b *strings.Builder, body []byte, truncated bool) {
+	if len(body) == 0 {
+		return
+	}
+	b.WriteString("\n")
+	b.Write(body)
+	if truncated {
+		b.WriteString("...(truncated)")
+	}
+	b.WriteString("\n")
+}
+
+func truncate(body []byte, limit int) ([]byte, bool) {
+	if limit <= 0 {
+		return nil, len(body) > 0
+	}
+	if len(body) > limit {
+		return body[:limit], true
+	}
+	return body, false
+}
+
+// sensitiveName matches names of headers, parameters, fields and elements holding secrets.
+var sensitiveName = regexp.MustCompile(`(?i)passw|secret|token|passphrase|private_?key|api_?key|crumb|cookie|authorization`)
+
+var (
+	// XML elements such as <password>...</password> in credential and job configurations.
+	sensitiveXML = regexp.MustCompile(`(?i)(<[\w.:-]*(?:passw|secret|token|passphrase|privatekey|apikey)[\w.:-]*(?:\s[^>]*)?>)[^<]*`)
+	// JSON members such as "password": "...".
+	sensitiveJSON = regexp.MustCompile(`(?i)("[^"]*(?:passw|secret|token|passphrase|private_?key|api_?key|crumb)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// Build parameters such as {"name": "DB_PASSWORD", "value": "..."}.
+	sensitiveParameter = regexp.MustCompile(`(?i)("name"\s*:\s*"[^"]*(?:passw|secret|token|passphrase|private_?key|api_?key)[^"]*"\s*,\s*"value"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+func redactHeader(header http.Header) http.Header {
+	if header == nil {
+		return nil
+	}
+	clone := make(http.Header, len(header))
+	for k, v := range header {
+		if sensitiveName.MatchString(k) {
+			clone[k] = []string{redacted}
+			continue
+		}
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
+}
+
+func redactURL(u *url.URL) string {
+	clone := *u
+	if clone.User != nil {
+		clone.User = url.UserPassword(clone.User.Username(), redacted)
+	}
+	if clone.RawQuery != "" {
+		clone.RawQuery = redactValues(clone.Query()).Encode()
+	}
+	return clone.String()
+}
+
+func redactValues(values url.Values) url.Values {
+	for k, v := range values {
+		for i := range v {
+			if sensitiveName.MatchString(k) {
+				v[i] = redacted
+			} else {
+				v[i] = redactText(v[i])
+			}
+		}
+	}
+	return values
+}
+
+// redactBody masks the secrets in a request or response body.
+func redactBody(contentType string, body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return []byte(redactValues(values).Encode())
+		}
+	}
+	return []byte(redactText(string(body)))
+}
+
+func redactText(text string) string {
+	text = sensitiveXML.ReplaceAllString(text, "${1}"+redacted)
+	text = sensitiveParameter.ReplaceAllString(text, `${1}"`+redacted+`"`)
+	text = sensitiveJSON.ReplaceAllString(text, `${1}"`+redacted+`"`)
+	return text
+}
@@ -0,0 +1,123 @@
+package gojenkins
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// TreeField is a field of a tree query. Fields holding objects or lists select their
+// own sub-fields, and lists can be cut to a range of elements.
+type TreeField struct {
+	name     string
+	children []TreeField
+	span     string
+}
+
+// Field selects name and, for objects and lists, the given sub-fields, e.g.
+//
+//	Field("builds", Field("number"), Field("result")).First(10)
+func Field(name string, children ...TreeField) TreeField {
+	return TreeField{name: name, children: children}
+}
+
+// Fields selects several plain fields.
+func Fields(names ...string) []TreeField {
+	fields := make([]TreeField, len(names))
+	for i, name := range names {
+		fields[i] = Field(name)
+	}
+	return fields
+}
+
+// Range keeps the list elements from index from up to, but not including, index to.
+func (f TreeField) Range(from int, to int) TreeField {
+	f.span = "{" + strconv.Itoa(from) + "," + strconv.Itoa(to) + "}"
+	return f
+}
+
+// First keeps the first n list elements.
+func (f TreeField) First(n int) TreeField {
+	f.span = "{," + strconv.Itoa(n) + "}"
+	return f
+}
+
+// From keeps the list elements from index from on.
+func (f TreeField) From(from int) TreeField {
+	f.span = "{" + strconv.Itoa(from) + ",}"
+	return f
+}
+
+func (f TreeField) String() string {
+	var b strings.Builder
+	f.write(&b)
+	return b.String()
+}
+
+func (f TreeField) write(b *strings.Builder) {
+	b.WriteString(f.name)
+	if len(f.children) > 0 {
+		b.WriteString("[")
+		writeFields(b, f.children)
+		b.WriteString("]")
+	}
+	b.WriteString(f.span)
+}
+
+func writeFields(b *strings.Builder, fields []TreeField) {
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		field.write(b)
+	}
+}
+
+// Tree selects the fields returned by the Jenkins remote API, through its tree and depth
+// parameters. The zero Tree selects the whole document at the default depth.
+// More about tree here: https://www.jenkins.io/doc/book/using/remote-access-api/
+type Tree struct {
+	fields   []TreeField
+	depth    int
+	hasDepth bool
+}
+
+// NewTree selects fields.
+func NewTree(fields ...TreeField) Tree {
+	return Tree{fields: fields}
+}
+
+// Depth sets how deep nested objects are expanded.
+func (t Tree) Depth(depth int) Tree {
+	t.depth = depth
+	t.hasDepth = true
+	return t
+}
+
+// String returns the value of the tree parameter.
+func (t Tree) String() string {
+	var b strings.Builder
+	writeFields(&b, t.fields)
+	return b.String()
+}
+
+// query returns the query parameters of t, nil for the zero Tree.
+func (t Tree) query() map[string]string {
+	qr := map[string]string{}
+	if len(t.fields) > 0 {
+		qr["tree"] = t.String()
+	}
+	if t.hasDepth {
+		qr["depth"] = strconv.Itoa(t.depth)
+	}
+	if len(qr) == 0 {
+		return nil
+	}
+	return qr
+}
+
+// Query fetches the fields selected by tree of the object at path, e.g. "/job/app", into out.
+func (j *Jenkins) Query(ctx context.Context, path string, tree Tree, out interface{}) error {
+	_, err := j.Requester.GetJSON(ctx, path, out, tree.query())
+	return err
+}
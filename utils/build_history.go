@@ -1,21 +1,28 @@
 package utils
 
 import (
-	"github.com/reaperhero/client-jenkins-go"
 	"golang.org/x/net/html"
 	"io"
 	"strconv"
 	"strings"
 )
 
+// History is a build listed in the build history of a job.
+type History struct {
+	BuildDisplayName string
+	BuildNumber      int
+	BuildStatus      string
+	BuildTimestamp   int64
+}
+
 // Parse jenkins ajax response in order find the current jenkins build history
-func ParseBuildHistory(d io.Reader) []*gojenkins.History {
+func ParseBuildHistory(d io.Reader) []*History {
 	z := html.NewTokenizer(d)
 	depth := 0
 	buildRowCellDepth := -1
-	builds := make([]*gojenkins.History, 0)
+	builds := make([]*History, 0)
 	isInsideDisplayName := false
-	var curBuild *gojenkins.History
+	var curBuild *History
 	for {
 		tt := z.Next()
 		switch tt {
@@ -47,7 +54,7 @@ func ParseBuildHistory(d io.Reader) []*gojenkins.History {
 				if string(tn) == "td" {
 					if hasCSSClass(a, "build-row-cell") {
 						buildRowCellDepth = depth
-						curBuild = &gojenkins.History{}
+						curBuild = &History{}
 						builds = append(builds, curBuild)
 					}
 				}
@@ -0,0 +1,99 @@
+package gojenkins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a Jenkins version such as 2.387.3. Versions compare numerically part by
+// part, a missing part counts as zero.
+type Version struct {
+	parts []int
+	raw   string
+}
+
+// ParseVersion parses the dotted numbers at the start of s. Suffixes such as
+// "-SNAPSHOT" or " (private-build)" are ignored.
+func ParseVersion(s string) (Version, error) {
+	raw := strings.TrimSpace(s)
+	numbers := raw
+	if i := strings.IndexFunc(numbers, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }); i >= 0 {
+		numbers = numbers[:i]
+	}
+	numbers = strings.TrimSuffix(numbers, ".")
+	if numbers == "" {
+		return Version{}, fmt.Errorf("gojenkins: invalid version %q", s)
+	}
+	var parts []int
+	for _, field := range strings.Split(numbers, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return Version{}, fmt.Errorf("gojenkins: invalid version %q", s)
+		}
+		parts = append(parts, n)
+	}
+	return Version{parts: parts, raw: raw}, nil
+}
+
+// Compare returns -1, 0 or +1 when v is older than, equal to or newer than other.
+func (v Version) Compare(other Version) int {
+	for i := 0; i < len(v.parts) || i < len(other.parts); i++ {
+		a, b := 0, 0
+		if i < len(v.parts) {
+			a = v.parts[i]
+		}
+		if i < len(other.parts) {
+			b = other.parts[i]
+		}
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast reports whether v is the same as or newer than other.
+func (v Version) AtLeast(other Version) bool {
+	return v.Compare(other) >= 0
+}
+
+// IsZero reports whether v is unknown.
+func (v Version) IsZero() bool {
+	return len(v.parts) == 0
+}
+
+func (v Version) String() string {
+	return v.raw
+}
+
+// ParsedVersion parses the version Jenkins reported to Init, Info or ProbeCapabilities.
+func (j *Jenkins) ParsedVersion() (Version, error) {
+	j.capsMu.Lock()
+	version := j.Version
+	j.capsMu.Unlock()
+	return ParseVersion(version)
+}
+
+func (j *Jenkins) setVersion(version string) {
+	j.capsMu.Lock()
+	j.Version = version
+	j.capsMu.Unlock()
+}
+
+// VersionAtLeast reports whether Jenkins runs version min or newer, e.g. j.VersionAtLeast("2.387").
+// It is false while the version is unknown, call Init first.
+func (j *Jenkins) VersionAtLeast(min string) bool {
+	v, err := j.ParsedVersion()
+	if err != nil {
+		return false
+	}
+	m, err := ParseVersion(min)
+	if err != nil {
+		return false
+	}
+	return v.AtLeast(m)
+}
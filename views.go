@@ -2,8 +2,6 @@ package gojenkins
 
 import (
 	"context"
-	"errors"
-	"strconv"
 )
 
 type View struct {
@@ -39,7 +37,7 @@ func (v *View) AddJob(ctx context.Context, name string) (bool, error) {
 	if resp.StatusCode == 200 {
 		return true, nil
 	}
-	return false, errors.New(strconv.Itoa(resp.StatusCode))
+	return false, newAPIError(resp)
 }
 
 // Returns True if successfully deleted Job, otherwise false
@@ -53,7 +51,7 @@ func (v *View) DeleteJob(ctx context.Context, name string) (bool, error) {
 	if resp.StatusCode == 200 {
 		return true, nil
 	}
-	return false, errors.New(strconv.Itoa(resp.StatusCode))
+	return false, newAPIError(resp)
 }
 
 func (v *View) GetDescription() string {
@@ -79,3 +77,14 @@ func (v *View) Poll(ctx context.Context) (int, error) {
 	}
 	return response.StatusCode, nil
 }
+
+// PollFields fetches only the fields selected by tree. Fields left out are zero in Raw.
+func (v *View) PollFields(ctx context.Context, tree Tree) (int, error) {
+	raw := new(ViewResponse)
+	response, err := v.Jenkins.Requester.GetJSON(ctx, v.Base, raw, tree.query())
+	if err != nil {
+		return 0, err
+	}
+	v.Raw = raw
+	return response.StatusCode, nil
+}
@@ -0,0 +1,189 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sync"
+)
+
+// SkipFolder is returned by a WalkFunc to skip the items of the folder it was called with.
+var SkipFolder = errors.New("gojenkins: skip this folder")
+
+// walkLevels is how many levels of folders a single request of WalkJobs fetches.
+const walkLevels = 3
+
+// defaultWalkConcurrency is the number of requests in flight when WalkOptions.Concurrency is not set.
+const defaultWalkConcurrency = 4
+
+// Item is a job or folder visited by WalkJobs.
+type Item struct {
+	// FullName is the name of the item with the names of its folders, e.g. team/app/main.
+	FullName string
+	Name     string
+	// Class is the _class of the item, e.g. hudson.model.FreeStyleProject.
+	Class string
+	URL   string
+	// Color is the ball color of a job, empty for folders.
+	Color string
+	// Depth is 1 for the items at the top of the walk, 2 for their items and so on.
+	Depth int
+	// Folder is true for items holding other items, like folders, multibranch
+	// projects and organization folders.
+	Folder bool
+}
+
+// WalkFunc is called by WalkJobs for every item. Returning SkipFolder skips the
+// items of a folder, any other error stops the walk and is returned by WalkJobs.
+type WalkFunc func(item Item) error
+
+// WalkOptions configure WalkJobs.
+type WalkOptions struct {
+	// Folder is the full name of the folder to walk, the root if empty.
+	Folder string
+	// MaxDepth stops the walk at items of that depth, 0 means no limit.
+	MaxDepth int
+	// Concurrency limits the requests in flight, 4 if not set.
+	Concurrency int
+}
+
+// walkNode is an item as returned by the tree query of WalkJobs. Jobs is nil
+// for items that are not folders.
+type walkNode struct {
+	Class string      `json:"_class"`
+	Name  string      `json:"name"`
+	URL   string      `json:"url"`
+	Color string      `json:"color"`
+	Jobs  *[]walkNode `json:"jobs"`
+}
+
+// walkTree selects levels of items, and the names of the items below the last
+// level to tell which of them are folders.
+func walkTree(levels int) TreeField {
+	fields := Fields("name", "url", "color")
+	if levels > 1 {
+		fields = append(fields, walkTree(levels-1))
+	} else {
+		fields = append(fields, Field("jobs", Field("name")))
+	}
+	return Field("jobs", fields...)
+}
+
+// WalkJobs calls fn for every job and folder below opts.Folder, at any depth,
+// folders before their items. Items are fetched with tree queries of several
+// levels at once, and folders deeper than that are fetched opts.Concurrency at
+// a time. fn is never called concurrently, but the items of folders fetched
+// separately may come in any order.
+func (j *Jenkins) WalkJobs(ctx context.Context, opts WalkOptions, fn WalkFunc) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultWalkConcurrency
+	}
+	w := &walker{
+		jenkins: j,
+		ctx:     ctx,
+		cancel:  cancel,
+		opts:    opts,
+		fn:      fn,
+		slots:   make(chan struct{}, concurrency),
+	}
+	w.fetch(path.Clean("/" + opts.Folder)[1:], 0)
+	w.wg.Wait()
+	if w.err == nil {
+		// The caller's context was done.
+		return ctx.Err()
+	}
+	return w.err
+}
+
+type walker struct {
+	jenkins *Jenkins
+	ctx     context.Context
+	cancel  context.CancelFunc
+	opts    WalkOptions
+	fn      WalkFunc
+	slots   chan struct{}
+	wg      sync.WaitGroup
+	// mu serializes the calls of fn and guards err.
+	mu  sync.Mutex
+	err error
+}
+
+func (w *walker) fail(err error) {
+	w.mu.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.mu.Unlock()
+	w.cancel()
+}
+
+// fetch visits the items of the folder fullName, whose depth is depth.
+func (w *walker) fetch(fullName string, depth int) {
+	levels := walkLevels
+	if w.opts.MaxDepth > 0 && w.opts.MaxDepth-depth < levels {
+		levels = w.opts.MaxDepth - depth
+	}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		select {
+		case w.slots <- struct{}{}:
+		case <-w.ctx.Done():
+			return
+		}
+		base := JobPath(fullName)
+		if base == "" {
+			base = "/"
+		}
+		var folder walkNode
+		err := w.jenkins.Query(w.ctx, base, NewTree(walkTree(levels)), &folder)
+		<-w.slots
+		if err != nil {
+			w.fail(err)
+			return
+		}
+		if folder.Jobs != nil {
+			w.visit(*folder.Jobs, fullName, depth+1, levels)
+		}
+	}()
+}
+
+// visit calls fn for nodes, the items at depth of the folder parent, and goes on
+// with the levels - 1 levels of items fetched below them.
+func (w *walker) visit(nodes []walkNode, parent string, depth int, levels int) {
+	for _, node := range nodes {
+		if w.ctx.Err() != nil {
+			return
+		}
+		item := Item{
+			FullName: path.Join(parent, node.Name),
+			Name:     node.Name,
+			Class:    node.Class,
+			URL:      node.URL,
+			Color:    node.Color,
+			Depth:    depth,
+			Folder:   node.Jobs != nil,
+		}
+		w.mu.Lock()
+		err := w.fn(item)
+		w.mu.Unlock()
+		if err == SkipFolder {
+			continue
+		}
+		if err != nil {
+			w.fail(err)
+			return
+		}
+		if !item.Folder || (w.opts.MaxDepth > 0 && depth >= w.opts.MaxDepth) {
+			continue
+		}
+		if levels > 1 {
+			w.visit(*node.Jobs, item.FullName, depth+1, levels-1)
+		} else {
+			w.fetch(item.FullName, depth)
+		}
+	}
+}